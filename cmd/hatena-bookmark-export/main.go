@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/bookmarkexport"
+	"github.com/ueokande/hatenactl/pkg/hatena/auth"
+	"github.com/ueokande/hatenactl/pkg/hatena/bookmark"
+	"github.com/ueokande/hatenactl/pkg/hatena/retry"
+)
+
+var (
+	flgAuth        = flag.String("auth", "", "authorization mode (wsse | oauth1) (default mode of --account, or oauth1)")
+	flgAccount     = flag.String("account", "", "account name in the credentials store")
+	flgCredentials = flag.String("credentials", "", "path to the credentials store (default in the user config directory)")
+	flgFeedURL     = flag.String("feed-url", bookmark.DefaultFeedURL, "URL of the Atom feed of the bookmarks")
+	flgOut         = flag.String("out", "bookmarks.ndjson", "path to the NDJSON output, which is also read to fetch only new bookmarks")
+	flgHTML        = flag.String("html", "bookmarks.html", "path to the Netscape bookmark HTML output (disabled if empty)")
+	flgDelay       = flag.Duration("delay", 1*time.Second, "delay between fetching pages")
+	flgAttempts    = flag.Int("max-attempts", retry.DefaultMaxAttempts, "maximum number of attempts of each request")
+)
+
+func newHTTPClient() (*http.Client, error) {
+	opts := auth.Options{
+		Mode:            *flgAuth,
+		Account:         *flgAccount,
+		CredentialsPath: *flgCredentials,
+	}
+	p, err := opts.Provider()
+	if err != nil {
+		return nil, err
+	}
+	err = auth.CheckScopes(p, auth.ScopeReadPublic, auth.ScopeReadPrivate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v: private bookmarks may not be exported\n", err)
+	}
+
+	client := p.NewHTTPClient()
+	client.Transport = &retry.Transport{
+		MaxAttempts:  *flgAttempts,
+		RoundTripper: client.Transport,
+	}
+	return client, nil
+}
+
+// readRecords reads the records of the last backup.  It returns no records
+// if the backup does not exist.
+func readRecords(path string) ([]bookmarkexport.Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := bookmarkexport.ReadNDJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// writeFile writes the file by fn via a temporary file, to keep the last
+// backup on failures.
+func writeFile(path string, fn func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = fn(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func run(ctx context.Context) error {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return err
+	}
+
+	known, err := readRecords(*flgOut)
+	if err != nil {
+		return err
+	}
+
+	e := &bookmarkexport.Exporter{
+		Client: &bookmark.Client{
+			HTTPClient: httpClient,
+			FeedURL:    *flgFeedURL,
+		},
+		Delay: *flgDelay,
+	}
+	newer, err := e.Fetch(ctx, known)
+	if err != nil {
+		return fmt.Errorf("unable to fetch bookmarks: %w", err)
+	}
+	records := bookmarkexport.Merge(newer, known)
+
+	err = writeFile(*flgOut, func(w io.Writer) error {
+		return bookmarkexport.WriteNDJSON(w, records)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", *flgOut, err)
+	}
+	if len(*flgHTML) > 0 {
+		err = writeFile(*flgHTML, func(w io.Writer) error {
+			return bookmarkexport.WriteNetscapeHTML(w, "Hatena Bookmarks", records)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to write %s: %w", *flgHTML, err)
+		}
+	}
+
+	fmt.Printf("exported %d new bookmarks (%d in total)\n", len(newer), len(records))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	err := run(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -6,22 +6,49 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/ueokande/hatenactl/pkg/credentials"
+	"github.com/ueokande/hatenactl/pkg/hatena/auth"
 	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
+	"golang.org/x/term"
 )
 
 var (
 	OAuthConsumerKey    = os.Getenv("OAUTH_CONSUMER_KEY")
 	OAuthConsumerSecret = os.Getenv("OAUTH_CONSUMER_SECRET")
 
-	flgScope = flag.String("scope", "read_public", "comma-separated scoped")
+	flgScope        = flag.String("scope", "read_public", "comma-separated scoped")
+	flgInitiateURL  = flag.String("initiate-url", "https://www.hatena.com/oauth/initiate", "URL to get a temporary credential")
+	flgAuthorizeURL = flag.String("authorize-url", "https://www.hatena.ne.jp/oauth/authorize", "URL to authorize the app by the user")
+	flgTokenURL     = flag.String("token-url", "https://www.hatena.com/oauth/token", "URL to get an access token")
+	flgLoopback     = flag.Bool("loopback", false, "receive the verification code on a temporary server on 127.0.0.1")
+	flgTimeout      = flag.Duration("timeout", 5*time.Minute, "time to wait for the authorization on --loopback")
+	flgAccount      = flag.String("account", "", "account name to save the token in the credentials store")
+	flgCredentials  = flag.String("credentials", "", "path to the credentials store (default in the user config directory)")
 )
 
+// stdin is a reader of the standard input shared by the verification code and
+// the passphrase, not to lose the buffered input
+var stdin = bufio.NewReader(os.Stdin)
+
+// passphraseInput returns os.Stdin to read the passphrase without echo if it is
+// a terminal.  A terminal delivers the input line by line, so stdin has no
+// buffered input after reading the verification code.
+func passphraseInput() io.Reader {
+	if term.IsTerminal(int(os.Stdin.Fd())) && stdin.Buffered() == 0 {
+		return os.Stdin
+	}
+	return stdin
+}
+
 func openURL(url string) error {
 	switch runtime.GOOS {
 	case "linux":
@@ -46,18 +73,37 @@ func run(ctx context.Context) error {
 		Signer:      &oauth1.HMACSHA1{ConsumerSecret: OAuthConsumerSecret},
 		ConsumerKey: OAuthConsumerKey,
 
-		TemporaryCredentialURI:        "https://www.hatena.com/oauth/initiate",
-		ResourceOwnerAuthorizationURI: "https://www.hatena.ne.jp/oauth/authorize",
-		TokenRequestURI:               "https://www.hatena.com/oauth/token",
+		TemporaryCredentialURI:        *flgInitiateURL,
+		ResourceOwnerAuthorizationURI: *flgAuthorizeURL,
+		TokenRequestURI:               *flgTokenURL,
+
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
 	}
 
 	q := url.Values{}
-	q.Set("scope", *flgScope)
-	token, err := client.Initiate(ctx, oauth1.CallbackOOB, q)
+	q.Set("scope", strings.Join(auth.ParseScopes(*flgScope), ","))
+
+	callbackURL := oauth1.CallbackOOB
+	var cs *oauth1.CallbackServer
+	if *flgLoopback {
+		var err error
+		cs, err = oauth1.NewCallbackServer("127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("unable to start a callback server: %w", err)
+		}
+		defer cs.Close()
+		callbackURL = cs.URL()
+	}
+
+	token, err := client.Initiate(ctx, callbackURL, q)
 	if err != nil {
 		return fmt.Errorf("unable to initiate oauth app: %w", err)
 	}
 
+	if cs != nil {
+		cs.Expect(token.Token)
+	}
+
 	authzURL, err := client.GetAuthorizeURL(ctx, token.Token)
 	if err != nil {
 		return fmt.Errorf("unable to authorize oauth app: %w", err)
@@ -66,12 +112,24 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("unable to open URL: %w", err)
 	}
-	fmt.Print("Enter verification code: ")
 
-	r := bufio.NewReader(os.Stdin)
-	verifier, err := r.ReadString('\n')
-	if err != nil {
-		return err
+	var verifier string
+	if cs != nil {
+		fmt.Println("Waiting for the authorization on the browser...")
+
+		ctx, cancel := context.WithTimeout(ctx, *flgTimeout)
+		defer cancel()
+		verifier, err = cs.Wait(ctx, token.Token)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Print("Enter verification code: ")
+
+		verifier, err = stdin.ReadString('\n')
+		if err != nil {
+			return err
+		}
 	}
 
 	token, err = client.GetAccessToken(ctx, token, strings.TrimSpace(verifier))
@@ -79,11 +137,45 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("unable to get access toke: %w", err)
 	}
 	fmt.Println("Verification succeeded")
+
+	if len(*flgAccount) > 0 {
+		return saveAccount(token)
+	}
 	fmt.Println("oauth_token: " + token.Token)
 	fmt.Println("oauth_token_secret: " + token.Secret)
 	return nil
 }
 
+func saveAccount(token oauth1.Token) error {
+	path := *flgCredentials
+	if len(path) == 0 {
+		var err error
+		path, err = credentials.DefaultPath()
+		if err != nil {
+			return err
+		}
+	}
+	passphrase, err := credentials.ReadPassphrase(os.Stderr, passphraseInput())
+	if err != nil {
+		return err
+	}
+
+	store := &credentials.Store{Path: path, Passphrase: passphrase}
+	err = store.Put(*flgAccount, credentials.Account{
+		Auth:                "oauth1",
+		OAuthConsumerKey:    OAuthConsumerKey,
+		OAuthConsumerSecret: OAuthConsumerSecret,
+		OAuthToken:          token.Token,
+		OAuthTokenSecret:    token.Secret,
+		OAuthScopes:         auth.ParseScopes(*flgScope),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to save the account: %w", err)
+	}
+	fmt.Printf("saved account %q to %s\n", *flgAccount, path)
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
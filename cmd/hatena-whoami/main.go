@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/auth"
+	"github.com/ueokande/hatenactl/pkg/hatena/user"
+)
+
+var (
+	flgAuth        = flag.String("auth", "", "authorization mode (wsse | oauth1) (default mode of --account, or oauth1)")
+	flgAccount     = flag.String("account", "", "account name in the credentials store")
+	flgCredentials = flag.String("credentials", "", "path to the credentials store (default in the user config directory)")
+	flgEndpoint    = flag.String("endpoint", user.DefaultBaseURL, "base URL of the user API")
+	flgScope       = flag.String("require-scope", "", "comma-separated scopes to check the token has")
+)
+
+func run(ctx context.Context) error {
+	opts := auth.Options{
+		Mode:            *flgAuth,
+		Account:         *flgAccount,
+		CredentialsPath: *flgCredentials,
+	}
+	p, err := opts.Provider()
+	if err != nil {
+		return err
+	}
+
+	client := &user.Client{
+		HTTPClient: p.NewHTTPClient(),
+		BaseURL:    *flgEndpoint,
+	}
+	u, err := client.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get the user: %w", err)
+	}
+
+	fmt.Println("Hatena ID: " + u.URLName)
+	fmt.Println("Display name: " + u.DisplayName)
+	fmt.Println("Profile image: " + u.ProfileImageURL)
+
+	s, ok := p.(auth.Scoper)
+	if !ok {
+		fmt.Printf("Scopes: not limited (%s)\n", p.Name())
+		return nil
+	}
+	scopes := s.GrantedScopes()
+	if scopes == nil {
+		fmt.Println("Scopes (requested at setup): unknown")
+	} else {
+		fmt.Println("Scopes (requested at setup): " + strings.Join(scopes, ", "))
+	}
+
+	if len(*flgScope) == 0 {
+		return nil
+	}
+	err = auth.CheckScopes(p, auth.ParseScopes(*flgScope)...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	err := run(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,59 +2,43 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 
 	"github.com/ueokande/hatenactl/pkg/crawler"
+	"github.com/ueokande/hatenactl/pkg/hatena/auth"
 	"github.com/ueokande/hatenactl/pkg/hatena/blog"
-	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
-	"github.com/ueokande/hatenactl/pkg/hatena/wsse"
+	"github.com/ueokande/hatenactl/pkg/hatena/retry"
 )
 
 var (
-	OAuthConsumerKey    = os.Getenv("OAUTH_CONSUMER_KEY")
-	OAuthConsumerSecret = os.Getenv("OAUTH_CONSUMER_SECRET")
-	OAuthToken          = os.Getenv("OAUTH_TOKEN")
-	OAuthTokenSecret    = os.Getenv("OAUTH_TOKEN_SECRET")
-	WSSEUserName        = os.Getenv("WSSE_USERNAME")
-	WSSEPassword        = os.Getenv("WSSE_PASSWORD")
-
-	flgAuth      = flag.String("auth", "oauth1", "authorization mode (wsse | oauth1)")
-	flgHatenaID  = flag.String("hatena-id", "", "hatena account id")
-	flgBlogID    = flag.String("blog-id", "", "hatena blog id")
-	flgOutDir    = flag.String("out-dir", os.TempDir(), "directory where output to")
-	flgUrlPrefix = flag.String("url-prefix", "", "prefix of the path in URL in published site")
-	flgCSSPath   = flag.String("css-path", "", "path to css to load in pages")
+	flgAuth        = flag.String("auth", "", "authorization mode (wsse | oauth1) (default mode of --account, or oauth1)")
+	flgHatenaID    = flag.String("hatena-id", "", "hatena account id")
+	flgBlogID      = flag.String("blog-id", "", "hatena blog id")
+	flgOutDir      = flag.String("out-dir", os.TempDir(), "directory where output to (deprecated: use --out dir:<path>)")
+	flgOut         = flag.String("out", "", "output target (dir:<path> | tar:<path> | zip:<path>) (default dir:<--out-dir>)")
+	flgUrlPrefix   = flag.String("url-prefix", "", "prefix of the path in URL in published site")
+	flgCSSPath     = flag.String("css-path", "", "path to css to load in pages")
+	flgEndpoint    = flag.String("endpoint", blog.DefaultBaseURL, "base URL of the blog Atom API")
+	flgFull        = flag.Bool("full", false, "save all entries even if they are not edited since the last crawl")
+	flgJobs        = flag.Int("jobs", 4, "number of entries saved concurrently")
+	flgImageJobs   = flag.Int("image-jobs", 4, "number of images downloaded concurrently in each entry")
+	flgHostConns   = flag.Int("max-conns-per-host", 4, "maximum number of concurrent downloads from each host (0 for no limit)")
+	flgKeepGoing   = flag.Bool("keep-going", false, "continue on failures of entries and images, and exit with 2 if any failed")
+	flgReport      = flag.String("failure-report", "hatenacrawl-failures.json", "path to the JSON report of the failures on --keep-going")
+	flgResume      = flag.Bool("resume", false, "continue the interrupted crawl from the checkpoint in the output (dir: only)")
+	flgAttempts    = flag.Int("max-attempts", retry.DefaultMaxAttempts, "maximum number of attempts of each request")
+	flgAccount     = flag.String("account", "", "account name in the credentials store")
+	flgCredentials = flag.String("credentials", "", "path to the credentials store (default in the user config directory)")
 )
 
 func validate() error {
-	if *flgAuth == "oauth1" {
-		if len(OAuthConsumerKey) == 0 {
-			return errors.New("OAUTH_CONSUMER_KEY not set")
-		}
-		if len(OAuthConsumerSecret) == 0 {
-			return errors.New("OAUTH_CONSUMER_SECRET not set")
-		}
-		if len(OAuthToken) == 0 {
-			return errors.New("OAUTH_TOKEN not set")
-		}
-		if len(OAuthTokenSecret) == 0 {
-			return errors.New("OAUTH_TOKEN_SECRET not set")
-		}
-	} else if *flgAuth == "wsse" {
-		if len(WSSEUserName) == 0 {
-			return errors.New("WSSE_USERNAME not set")
-		}
-		if len(WSSEPassword) == 0 {
-			return errors.New("WSSE_PASSWORD not set")
-		}
-	} else {
-		return fmt.Errorf("unknown authorization mode %q", *flgAuth)
-	}
-
 	if len(*flgHatenaID) == 0 {
 		return errors.New("--hatena-id not set")
 	}
@@ -64,16 +48,34 @@ func validate() error {
 	return nil
 }
 
-func newHTTPClient() *http.Client {
-	if *flgAuth == "oauth1" {
-		return oauth1.NewHTTPClient(
-			OAuthConsumerKey, OAuthConsumerSecret,
-			OAuthToken, OAuthTokenSecret,
-		)
-	} else if *flgAuth == "wsse" {
-		return wsse.NewHTTPClient(WSSEUserName, WSSEPassword)
+func newHTTPClient() (*http.Client, error) {
+	opts := auth.Options{
+		Mode:            *flgAuth,
+		Account:         *flgAccount,
+		CredentialsPath: *flgCredentials,
+	}
+	p, err := opts.Provider()
+	if err != nil {
+		return nil, err
+	}
+	err = auth.CheckScopes(p, auth.ScopeReadPublic, auth.ScopeReadPrivate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v: drafts may not be crawled\n", err)
+	}
+
+	client := p.NewHTTPClient()
+	client.Transport = newRetryTransport(client.Transport)
+	return client, nil
+}
+
+// newRetryTransport wraps the transport to retry requests on transient
+// failures.  The retry transport is placed in front of the authentication
+// transport to sign the request on each attempt.
+func newRetryTransport(rt http.RoundTripper) http.RoundTripper {
+	return &retry.Transport{
+		MaxAttempts:  *flgAttempts,
+		RoundTripper: rt,
 	}
-	panic("unknown authorization mode " + *flgAuth)
 }
 
 func run(ctx context.Context) error {
@@ -81,17 +83,35 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return err
+	}
+
+	out := *flgOut
+	if len(out) == 0 {
+		out = "dir:" + *flgOutDir
+	}
+	store, err := crawler.OpenDataStore(out)
+	if err != nil {
+		return err
+	}
 
 	c := &crawler.Crawler{
 		HatenaID: *flgHatenaID,
 		BlogID:   *flgBlogID,
 		BlogClient: &blog.Client{
-			HTTPClient: newHTTPClient(),
+			HTTPClient: httpClient,
+			BaseURL:    *flgEndpoint,
 		},
-		CSSPath: *flgCSSPath,
-		DataStore: &crawler.DataStore{
-			Directory: *flgOutDir,
+		Downloader: &crawler.Downloader{
+			HTTPClient: &http.Client{
+				Transport: newRetryTransport(http.DefaultTransport),
+			},
+			MaxConnsPerHost: *flgHostConns,
 		},
+		CSSPath:   *flgCSSPath,
+		DataStore: store,
 		Path: &crawler.Path{
 			URLPrefix: *flgUrlPrefix,
 		},
@@ -109,16 +129,99 @@ func run(ctx context.Context) error {
 				CSSPaths: []string{*flgCSSPath},
 			},
 		},
+		Jobs:           *flgJobs,
+		ImageJobs:      *flgImageJobs,
+		IgnoreManifest: *flgFull,
+		KeepGoing:      *flgKeepGoing,
+		Resume:         *flgResume,
+	}
+	err = c.Start(ctx)
+	var partialErr *crawler.PartialError
+	var cerr error
+	if a, ok := store.(crawler.Aborter); ok && err != nil && !errors.As(err, &partialErr) {
+		// Keep the previous output on the failed crawl
+		cerr = a.Abort()
+	} else {
+		cerr = store.Close()
+	}
+
+	if errors.As(err, &partialErr) {
+		rerr := reportFailures(partialErr)
+		if rerr != nil {
+			return rerr
+		}
+	}
+	if err != nil && cerr != nil {
+		return fmt.Errorf("%v, and unable to close the output: %w", err, cerr)
+	}
+	if _, ok := store.(crawler.DataReader); ok && err != nil && ctx.Err() != nil {
+		return resumableError{err}
+	}
+	if err != nil {
+		return err
 	}
-	return c.Start(ctx)
+	return cerr
+}
+
+// resumableError is an error of the interrupted crawl, which is resumed from
+// the checkpoint in the output by --resume.
+type resumableError struct {
+	error
+}
+
+func (e resumableError) Unwrap() error {
+	return e.error
+}
+
+// reportFailures prints a summary of the failures, and writes the report to
+// the --failure-report.
+func reportFailures(e *crawler.PartialError) error {
+	for _, f := range e.Failures {
+		fmt.Fprintf(os.Stderr, "failed %s %s: %s\n", f.Kind, f.Path, f.Error)
+	}
+	fmt.Fprintf(os.Stderr, "%d entries, %d failed entries, %d failed assets, %d failed index pages\n",
+		e.Entries, e.Count(crawler.FailureEntry), e.Count(crawler.FailureAsset), e.Count(crawler.FailureIndex))
+
+	report, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(*flgReport, append(report, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write the failure report: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "wrote the failure report to", *flgReport)
+	return nil
 }
 
 func main() {
 	flag.Parse()
 
-	err := run(context.Background())
+	// Shut down cleanly on the first SIGINT to save the checkpoint.  The
+	// second SIGINT kills the process.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		signal.Stop(sigCh)
+		fmt.Fprintln(os.Stderr, "interrupted, shutting down...")
+		cancel()
+	}()
+
+	err := run(ctx)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var resumableErr resumableError
+		if errors.As(err, &resumableErr) {
+			fmt.Fprintln(os.Stderr, "run again with --resume to continue the crawl")
+		}
+
+		var partialErr *crawler.PartialError
+		if errors.As(err, &partialErr) {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
@@ -2,4 +2,8 @@ module github.com/ueokande/hatenactl
 
 go 1.14
 
-require golang.org/x/net v0.0.0-20200301022130-244492dfa37a
+require (
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
+	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1
+)
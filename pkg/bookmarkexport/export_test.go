@@ -0,0 +1,130 @@
+package bookmarkexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/bookmark"
+)
+
+// newFeedServer returns a server of the feed with n bookmarks, 2 bookmarks
+// per page.
+func newFeedServer(n int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		of, _ := strconv.Atoi(r.URL.Query().Get("of"))
+		fmt.Fprintln(w, `<feed version="0.3" xmlns="http://purl.org/atom/ns#" xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+		if of+2 < n {
+			fmt.Fprintf(w, `<link rel="next" href="http://%s/atom/feed?of=%d"/>`, r.Host, of+2)
+		}
+		for i := of; i < of+2 && i < n; i++ {
+			id := n - i
+			fmt.Fprintf(w, `<entry>
+  <id>tag:hatena.ne.jp,2005:bookmark-ueokande-%d</id>
+  <title>Page %d</title>
+  <link rel="related" href="https://example.com/%d"/>
+  <issued>2020-03-%02dT12:00:00+09:00</issued>
+  <summary>comment %d</summary>
+  <dc:subject>tag%d</dc:subject>
+</entry>`, id, id, id, id, id, id)
+		}
+		fmt.Fprintln(w, `</feed>`)
+	}))
+}
+
+func ids(records []Record) []string {
+	var ids []string
+	for _, rec := range records {
+		ids = append(ids, strings.TrimPrefix(rec.ID, "tag:hatena.ne.jp,2005:bookmark-ueokande-"))
+	}
+	return ids
+}
+
+func testExporterFetch(t *testing.T) {
+	server := newFeedServer(5)
+	defer server.Close()
+
+	e := &Exporter{Client: &bookmark.Client{
+		HTTPClient: server.Client(),
+		FeedURL:    server.URL + "/atom/feed",
+	}}
+	records, err := e.Fetch(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ids(records); !reflect.DeepEqual(v, []string{"5", "4", "3", "2", "1"}) {
+		t.Errorf("%v != %v", v, []string{"5", "4", "3", "2", "1"})
+	}
+	if records[0].URL != "https://example.com/5" || records[0].Comment != "comment 5" {
+		t.Errorf("unexpected record: %+v", records[0])
+	}
+
+	newer, err := e.Fetch(context.Background(), records[3:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ids(newer); !reflect.DeepEqual(v, []string{"5", "4", "3"}) {
+		t.Errorf("%v != %v", v, []string{"5", "4", "3"})
+	}
+	if v := ids(Merge(newer, records[2:])); !reflect.DeepEqual(v, []string{"5", "4", "3", "2", "1"}) {
+		t.Errorf("%v != %v", v, []string{"5", "4", "3", "2", "1"})
+	}
+}
+
+func testNDJSON(t *testing.T) {
+	records := []Record{
+		{ID: "1", URL: "https://example.com/?a=1&b=2", Tags: []string{"a"}, CreatedAt: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", URL: "https://example.com/", Comment: "<nice>"},
+	}
+	var buf bytes.Buffer
+	err := WriteNDJSON(&buf, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("%d != %d", n, 2)
+	}
+	got, err := ReadNDJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("%+v != %+v", got, records)
+	}
+}
+
+func testWriteNetscapeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteNetscapeHTML(&buf, "Bookmarks", []Record{{
+		URL:       "https://example.com/?a=1&b=2",
+		Title:     "Example",
+		Comment:   "<nice>",
+		Tags:      []string{"golang", "cli"},
+		CreatedAt: time.Unix(1583033696, 0),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `    <DT><A HREF="https://example.com/?a=1&amp;b=2" ADD_DATE="1583033696" TAGS="golang,cli">Example</A>
+    <DD>&lt;nice&gt;
+`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q does not contain %q", buf.String(), expected)
+	}
+	if !strings.HasPrefix(buf.String(), "<!DOCTYPE NETSCAPE-Bookmark-file-1>") {
+		t.Errorf("unexpected header: %q", buf.String())
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Run("ExporterFetch", testExporterFetch)
+	t.Run("NDJSON", testNDJSON)
+	t.Run("WriteNetscapeHTML", testWriteNetscapeHTML)
+}
@@ -0,0 +1,75 @@
+package bookmarkexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/bookmark"
+)
+
+// An Exporter fetches bookmarks of the user page by page.
+type Exporter struct {
+	Client *bookmark.Client
+	// Delay is a delay between fetching pages
+	Delay time.Duration
+}
+
+// Fetch fetches bookmarks newer than the known records, from newer ones.
+// It stops at the first bookmark in the known records or older than them,
+// so the re-run fetches only bookmarks since the last backup.  All
+// bookmarks are fetched if known is empty.
+func (e *Exporter) Fetch(ctx context.Context, known []Record) ([]Record, error) {
+	ids := make(map[string]bool)
+	var latest time.Time
+	for _, rec := range known {
+		ids[rec.ID] = true
+		if rec.CreatedAt.After(latest) {
+			latest = rec.CreatedAt
+		}
+	}
+
+	var records []Record
+	offset := 0
+	for {
+		feed, err := e.Client.ListBookmarks(ctx, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range feed.Entries {
+			rec, err := NewRecord(entry)
+			if err != nil {
+				return nil, err
+			}
+			if ids[rec.ID] || rec.CreatedAt.Before(latest) {
+				return records, nil
+			}
+			records = append(records, rec)
+		}
+
+		next, ok := feed.NextOffset()
+		if !ok || next <= offset || len(feed.Entries) == 0 {
+			return records, nil
+		}
+		offset = next
+
+		err = sleep(ctx, e.Delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleep waits for the duration or the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
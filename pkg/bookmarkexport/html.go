@@ -0,0 +1,38 @@
+package bookmarkexport
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteNetscapeHTML writes records in the Netscape bookmark file format,
+// which can be imported by web browsers.  Tags are written in the TAGS
+// attribute, and the comment is written in the DD element.
+func WriteNetscapeHTML(w io.Writer, title string, records []Record) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	bw.WriteString(`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n")
+	bw.WriteString("<TITLE>" + html.EscapeString(title) + "</TITLE>\n")
+	bw.WriteString("<H1>" + html.EscapeString(title) + "</H1>\n")
+	bw.WriteString("<DL><p>\n")
+	for _, rec := range records {
+		bw.WriteString(`    <DT><A HREF="` + html.EscapeString(rec.URL) + `"`)
+		bw.WriteString(` ADD_DATE="` + strconv.FormatInt(rec.CreatedAt.Unix(), 10) + `"`)
+		if len(rec.Tags) > 0 {
+			bw.WriteString(` TAGS="` + html.EscapeString(strings.Join(rec.Tags, ",")) + `"`)
+		}
+		title := rec.Title
+		if len(title) == 0 {
+			title = rec.URL
+		}
+		bw.WriteString(">" + html.EscapeString(title) + "</A>\n")
+		if len(rec.Comment) > 0 {
+			bw.WriteString("    <DD>" + html.EscapeString(rec.Comment) + "\n")
+		}
+	}
+	bw.WriteString("</DL><p>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,87 @@
+// Package bookmarkexport exports bookmarks of the Hatena Bookmark to NDJSON
+// and the Netscape bookmark file.
+package bookmarkexport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/bookmark"
+)
+
+// A Record is an exported bookmark.
+type Record struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Comment   string    `json:"comment"`
+	Tags      []string  `json:"tags"`
+	Permalink string    `json:"permalink"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewRecord returns a record of the bookmark in the feed.
+func NewRecord(e bookmark.FeedEntry) (Record, error) {
+	createdAt, err := time.Parse(time.RFC3339, e.Issued)
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid issued time of %s: %w", e.ID, err)
+	}
+	return Record{
+		ID:        e.ID,
+		URL:       e.URL(),
+		Title:     e.Title,
+		Comment:   e.Summary,
+		Tags:      e.Tags,
+		Permalink: e.Permalink(),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// ReadNDJSON reads records from newline-delimited JSON.
+func ReadNDJSON(r io.Reader) ([]Record, error) {
+	var records []Record
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var rec Record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// WriteNDJSON writes records as newline-delimited JSON.
+func WriteNDJSON(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, rec := range records {
+		err := enc.Encode(rec)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge returns records of newer ones followed by older ones.  Records in
+// older with the same ID as newer ones are dropped.
+func Merge(newer, older []Record) []Record {
+	ids := make(map[string]bool)
+	records := make([]Record, 0, len(newer)+len(older))
+	for _, recs := range [][]Record{newer, older} {
+		for _, rec := range recs {
+			if ids[rec.ID] {
+				continue
+			}
+			ids[rec.ID] = true
+			records = append(records, rec)
+		}
+	}
+	return records
+}
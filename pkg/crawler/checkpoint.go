@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/blog"
+)
+
+// CheckpointPath is a path to the checkpoint in the data store.
+const CheckpointPath = "checkpoint.json"
+
+const checkpointVersion = 1
+
+// A Checkpoint is a progress of the crawl saved after each page of the
+// entries.  The interrupted crawl is resumed from the checkpoint.
+type Checkpoint struct {
+	Version int `json:"version"`
+	// Page is a page token to continue listing from
+	Page string `json:"page"`
+	// Entries are the entries saved before the page.  The contents of the
+	// entries are stripped.
+	Entries []blog.Entry `json:"entries"`
+	// Failures are the failures of the entries in the KeepGoing mode
+	Failures []Failure `json:"failures,omitempty"`
+	// Completed is true if the crawl is finished
+	Completed bool `json:"completed"`
+}
+
+// ReadCheckpoint reads the checkpoint from the data store.  It returns nil
+// if the checkpoint is not stored.
+func ReadCheckpoint(s DataStore) (*Checkpoint, error) {
+	reader, ok := s.(DataReader)
+	if !ok {
+		return nil, errors.New("unable to read a checkpoint from the output")
+	}
+	r, err := reader.Reader(CheckpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var cp Checkpoint
+	err = json.NewDecoder(r).Decode(&cp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the checkpoint: %w", err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", cp.Version)
+	}
+	return &cp, nil
+}
+
+// WriteCheckpoint writes the checkpoint to the data store.  The contents of
+// the entries are stripped.
+func WriteCheckpoint(s DataStore, cp *Checkpoint) error {
+	stripped := *cp
+	stripped.Version = checkpointVersion
+	stripped.Entries = make([]blog.Entry, len(cp.Entries))
+	for i, e := range cp.Entries {
+		e.Summary = blog.Content{}
+		e.Content = blog.Content{}
+		e.FormattedContent = blog.Content{}
+		stripped.Entries[i] = e
+	}
+
+	data, err := json.Marshal(&stripped)
+	if err != nil {
+		return err
+	}
+	return writeFile(s, CheckpointPath, data)
+}
@@ -1,26 +1,29 @@
 package crawler
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
-	"path"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ueokande/hatenactl/pkg/hatena/blog"
 	"golang.org/x/net/html"
 )
 
-var downloader Downloader
-
 type Crawler struct {
 	BlogClient *blog.Client
-	DataStore  *DataStore
+	// Downloader downloads images in the entries.  The images are
+	// downloaded by http.DefaultClient if nil.
+	Downloader *Downloader
+	DataStore  DataStore
 	Path       *Path
 	CSSPath    string
 
@@ -28,70 +31,202 @@ type Crawler struct {
 	BlogID   string
 
 	Filters []Filter
+
+	// Jobs is the number of entries saved concurrently.  The entries are
+	// saved one by one if zero.
+	Jobs int
+	// ImageJobs is the number of images downloaded concurrently in each
+	// entry.  The images are downloaded one by one if zero.
+	ImageJobs int
+
+	// IgnoreManifest makes the crawler save all entries even if they are
+	// not edited since the last crawl.
+	IgnoreManifest bool
+
+	// KeepGoing makes the crawler continue on failures of entries, assets
+	// and index pages.  The failures are returned as *PartialError.
+	KeepGoing bool
+
+	// Resume makes the crawler continue from the checkpoint of the
+	// interrupted crawl.  The checkpoint is saved after each page if the
+	// DataStore is a DataReader.
+	Resume bool
 }
 
 func (c Crawler) Start(ctx context.Context) error {
-	byCategory := make(map[string][]blog.Entry)
-	byYear := make(map[int][]blog.Entry)
-	urlext := ImageURLExtractor{}
+	// The last manifest is used to skip unchanged entries, and to restore
+	// the records of the entries on resuming
+	last := NewManifest()
+	if !c.IgnoreManifest || c.Resume {
+		var err error
+		last, err = ReadManifest(c.DataStore)
+		if err != nil {
+			return err
+		}
+	}
+	options := c.optionsFingerprint()
+	prev := last
+	if c.IgnoreManifest || last.Options != options {
+		prev = NewManifest()
+	}
+	manifest := NewManifest()
+	manifest.Options = options
+	var mu sync.Mutex
+	failures := &failureList{}
+
+	// listed are the entries in the blog in the order of the feed
+	var listed []blog.Entry
+
+	// Restore the entries saved before the checkpoint
+	page := ""
+	if c.Resume {
+		cp, err := ReadCheckpoint(c.DataStore)
+		if err != nil {
+			return err
+		}
+		if cp != nil && !cp.Completed {
+			if last.Options != options {
+				return errors.New("unable to resume the crawl with different options")
+			}
+			for _, entry := range cp.Entries {
+				listed = append(listed, entry)
+				if m, ok := last.Entries[entry.ID]; ok {
+					manifest.Entries[entry.ID] = m
+				}
+			}
+			for _, f := range cp.Failures {
+				failures.add(f)
+			}
+			page = cp.Page
+			fmt.Printf("resume from the checkpoint with %d entries\n", len(cp.Entries))
+		}
+	}
 
-	// 1. Download entries and images contained in the entry
-	err := c.listAllEntries(ctx, func(ctx context.Context, entry blog.Entry) error {
-		if entry.FormattedContent.Type != "text/html" {
-			return errors.New("unknown content type: " + entry.FormattedContent.Type)
+	// checkpoint saves the manifest and the checkpoint to continue from the
+	// page, with the failures of the entries before the page
+	_, resumable := c.DataStore.(DataReader)
+	checkpoint := func(page string, entries []blog.Entry) error {
+		if !resumable {
+			return nil
 		}
-		for _, cat := range entry.Categories {
-			byCategory[cat.Term] = append(byCategory[cat.Term], entry)
+		ids := make(map[string]bool)
+		for _, e := range entries {
+			ids[e.ID] = true
 		}
-		byYear[entry.Published.Year()] = append(byYear[entry.Published.Year()], entry)
+		var fs []Failure
+		for _, f := range failures.sorted() {
+			if ids[f.EntryID] {
+				fs = append(fs, f)
+			}
+		}
+
+		m := NewManifest()
+		m.Options = options
+		mu.Lock()
+		for id, e := range last.Entries {
+			m.Entries[id] = e
+		}
+		for id, e := range manifest.Entries {
+			m.Entries[id] = e
+		}
+		mu.Unlock()
 
-		p := c.Path.EntryFilePath(entry)
-		w, err := c.DataStore.Writer(p)
+		err := WriteManifest(c.DataStore, m)
 		if err != nil {
 			return err
 		}
-		defer w.Close()
+		return WriteCheckpoint(c.DataStore, &Checkpoint{Page: page, Entries: entries, Failures: fs})
+	}
+	cpPage, cpEntries := page, len(listed)
 
-		root, err := html.Parse(strings.NewReader(entry.FormattedContent.Content))
-		if err != nil {
-			return fmt.Errorf("unable to parse as html: %w", err)
+	// fail records the failure in the KeepGoing mode, or returns the error.
+	// The cancellation is not a failure, and is always returned.
+	fail := func(f Failure, err error) error {
+		if !c.KeepGoing || errors.Is(err, context.Canceled) {
+			return err
 		}
+		f.Error = err.Error()
+		failures.add(f)
+		return nil
+	}
 
-		urls := urlext.ExtractImageURLs(root)
-		err = c.downloadImages(ctx, entry, urls)
+	// 1. Download entries and images contained in the entry.  The entries
+	// are saved concurrently.
+	g, gctx := newGroup(ctx, c.Jobs)
+	onPage := func(ctx context.Context, page string) error {
+		// Wait for the entries in the previous pages to be saved
+		err := g.Flush()
 		if err != nil {
 			return err
 		}
+		cpPage, cpEntries = page, len(listed)
+		return checkpoint(page, listed)
+	}
+	err := c.listAllEntries(gctx, page, onPage, func(ctx context.Context, entry blog.Entry) error {
+		listed = append(listed, entry)
+		if entry.FormattedContent.Type != "text/html" {
+			err := errors.New("unknown content type: " + entry.FormattedContent.Type)
+			return fail(Failure{Kind: FailureEntry, EntryID: entry.ID, Path: entry.Path()}, err)
+		}
 
-		for _, f := range c.Filters {
-			err = f.Process(entry, root)
+		g.Go(func() error {
+			m, err := c.saveEntry(ctx, entry, prev.Entries[entry.ID], failures)
 			if err != nil {
-				return fmt.Errorf("unable process a document: %w", err)
+				err = fmt.Errorf("unable process %s (%s): %w", entry.Path(), entry.ID, err)
+				return fail(Failure{Kind: FailureEntry, EntryID: entry.ID, Path: entry.Path()}, err)
 			}
+			mu.Lock()
+			manifest.Entries[entry.ID] = m
+			mu.Unlock()
+			return nil
+		})
+		return g.Err()
+	})
+	if werr := g.Wait(); werr != nil {
+		// The error of the entry takes priority over the cancellation of
+		// the listing
+		err = werr
+	}
+	if ctx.Err() != nil {
+		// Save the checkpoint on the interruption to resume the crawl.  The
+		// interrupted crawl is never completed, even if no entry failed.
+		cerr := checkpoint(cpPage, listed[:cpEntries])
+		if cerr != nil {
+			return cerr
 		}
-		err = html.Render(w, root)
-		if err != nil {
-			return err
+		if err == nil {
+			err = ctx.Err()
 		}
-
-		fmt.Println("saved", p)
-		return nil
-	})
+	}
 	if err != nil {
 		return err
 	}
 
+	// The saved entries are aggregated into the index pages in the order of
+	// the feed.  The entries failed to save are not linked.
+	byCategory := make(map[string][]blog.Entry)
+	byYear := make(map[int][]blog.Entry)
+	failed := failures.entries()
+	for _, entry := range listed {
+		if failed[entry.ID] {
+			continue
+		}
+		for _, cat := range entry.Categories {
+			byCategory[cat.Term] = append(byCategory[cat.Term], entry)
+		}
+		byYear[entry.Published.Year()] = append(byYear[entry.Published.Year()], entry)
+	}
+
 	// 2. Generate index page by a category
 	for cat, entries := range byCategory {
 		err := func(category string, entries []blog.Entry) error {
 			p := c.Path.CategoryFilePath(category)
-			f, err := c.DataStore.Writer(p)
+			var buf bytes.Buffer
+			err := c.RenderCategoryIndex(&buf, category, entries)
 			if err != nil {
 				return err
 			}
-			defer f.Close()
-
-			err = c.RenderCategoryIndex(f, category, entries)
+			err = writeFile(c.DataStore, p, buf.Bytes())
 			if err != nil {
 				return err
 			}
@@ -100,7 +235,11 @@ func (c Crawler) Start(ctx context.Context) error {
 			return nil
 		}(cat, entries)
 		if err != nil {
-			return fmt.Errorf("unable to create a category index '%s': %w", cat, err)
+			err = fmt.Errorf("unable to create a category index '%s': %w", cat, err)
+			err = fail(Failure{Kind: FailureIndex, Path: c.Path.CategoryFilePath(cat)}, err)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -114,13 +253,12 @@ func (c Crawler) Start(ctx context.Context) error {
 	for _, year := range years {
 		err := func(year int, entries []blog.Entry) error {
 			p := c.Path.ArchiveFilePath(year)
-			f, err := c.DataStore.Writer(p)
+			var buf bytes.Buffer
+			err := c.RenderArchiveIndex(&buf, year, entries)
 			if err != nil {
 				return err
 			}
-			defer f.Close()
-
-			err = c.RenderArchiveIndex(f, year, entries)
+			err = writeFile(c.DataStore, p, buf.Bytes())
 			if err != nil {
 				return err
 			}
@@ -129,101 +267,249 @@ func (c Crawler) Start(ctx context.Context) error {
 			return nil
 		}(year, byYear[year])
 		if err != nil {
-			return fmt.Errorf("unable to create an archive index '%d-01-01': %w", year, err)
+			err = fmt.Errorf("unable to create an archive index '%d-01-01': %w", year, err)
+			err = fail(Failure{Kind: FailureIndex, Path: c.Path.ArchiveFilePath(year)}, err)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	// 4. Generate landing page
 	err = func() error {
 		p := c.Path.LandingFilePath()
-		f, err := c.DataStore.Writer(p)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
 		var categories []string
 		for category := range byCategory {
 			categories = append(categories, category)
 		}
 		sort.Strings(categories)
-		err = c.RenderLanding(f, c.BlogID, categories, years)
+		var buf bytes.Buffer
+		err := c.RenderLanding(&buf, c.BlogID, categories, years)
+		if err != nil {
+			return err
+		}
+		err = writeFile(c.DataStore, p, buf.Bytes())
 		if err != nil {
 			return err
 		}
 		fmt.Println("saved", p)
 		return nil
 	}()
-	return err
-}
+	if err != nil {
+		err = fail(Failure{Kind: FailureIndex, Path: c.Path.LandingFilePath()}, err)
+		if err != nil {
+			return err
+		}
+	}
 
-func (c Crawler) downloadImages(ctx context.Context, entry blog.Entry, urls []string) error {
-	download := func(ctx context.Context, src string) error {
-		url, err := url.Parse(src)
+	// 5. Save the manifest for the next crawl
+	err = WriteManifest(c.DataStore, manifest)
+	if err != nil {
+		return err
+	}
+	if resumable {
+		err = WriteCheckpoint(c.DataStore, &Checkpoint{Completed: true})
 		if err != nil {
 			return err
 		}
-		basename := path.Base(url.Path)
+	}
+
+	if f := failures.sorted(); len(f) > 0 {
+		return &PartialError{Entries: len(listed), Failures: f}
+	}
+	return nil
+}
+
+// optionsFingerprint returns a fingerprint of the options rendering the
+// pages, which are the path, the CSS and the filters.
+func (c Crawler) optionsFingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%+v\n%q\n", *c.Path, c.CSSPath)
+	for _, f := range c.Filters {
+		fmt.Fprintf(h, "%T%+v\n", f, f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// saveEntry saves the entry and images contained in the entry.  It skips the
+// entry not edited since the last crawl.  It returns the record of the
+// saved entry.  In the KeepGoing mode, failures of the images are added to
+// the failures, and the entry is recorded as partial.  It returns an error if
+// the downloads are canceled.
+func (c Crawler) saveEntry(ctx context.Context, entry blog.Entry, last ManifestEntry, failures *failureList) (ManifestEntry, error) {
+	if !last.Partial && last.Edited.Equal(entry.Edited) && len(last.Files) > 0 && stored(c.DataStore, last.Files) {
+		fmt.Println("unchanged", entry.Path())
+		return last, nil
+	}
 
-		// convert long file name
-		if len(basename) > 127 {
-			basename = fmt.Sprintf("%X", sha1.Sum([]byte(basename)))
+	p := c.Path.EntryFilePath(entry)
+	root, err := html.Parse(strings.NewReader(entry.FormattedContent.Content))
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("unable to parse as html: %w", err)
+	}
+
+	urlext := ImageURLExtractor{}
+	urls := urlext.ExtractImageURLs(root)
+	results, err := c.downloadImages(ctx, entry, urls, last.Images)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	m := ManifestEntry{
+		Edited: entry.Edited,
+		Files:  []string{p},
+		Images: make(map[string]string),
+	}
+	for _, r := range results {
+		if errors.Is(r.Err, context.Canceled) {
+			return ManifestEntry{}, r.Err
+		}
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			failures.add(Failure{
+				Kind:    FailureAsset,
+				EntryID: entry.ID,
+				Path:    entry.Path(),
+				URL:     r.URL,
+				Error:   r.Err.Error(),
+			})
+			m.Partial = true
+			continue
 		}
+		m.Files = append(m.Files, r.Path)
+		m.Images[r.URL] = r.Hash
+	}
 
+	for _, f := range c.Filters {
+		err = f.Process(entry, root)
+		if err != nil {
+			return ManifestEntry{}, fmt.Errorf("unable process a document: %w", err)
+		}
+	}
+	var buf bytes.Buffer
+	err = html.Render(&buf, root)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	err = writeFile(c.DataStore, p, buf.Bytes())
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	fmt.Println("saved", p)
+	return m, nil
+}
+
+// imageResult is a result of downloading an image.
+type imageResult struct {
+	URL  string
+	Path string
+	Hash string
+	Err  error
+}
+
+// downloadImages downloads the images of the URLs in the entry concurrently.
+// The images with the same base name are saved as different files by
+// imageFileNames.  It skips images whose hash in the last manifest equals to the stored file.
+// It returns the results in the order of the URLs.  It returns the first
+// error unless the KeepGoing mode, which records the error in the result.
+func (c Crawler) downloadImages(ctx context.Context, entry blog.Entry, urls []string, last map[string]string) ([]imageResult, error) {
+	names := imageFileNames(urls)
+	download := func(ctx context.Context, src string) (string, string, error) {
+		_, err := url.Parse(src)
+		if err != nil {
+			return "", "", err
+		}
+		p := c.Path.ImageFilePath(entry, names[src])
+		if hash, ok := last[src]; ok && storedHash(c.DataStore, p) == hash {
+			fmt.Println("unchanged", p)
+			return p, hash, nil
+		}
+
+		downloader := c.Downloader
+		if downloader == nil {
+			downloader = &Downloader{}
+		}
 		resp, err := downloader.Download(ctx, src)
 		if err != nil {
-			return err
+			return "", "", err
 		}
 		defer resp.Close()
 
-		p := c.Path.ImageFilePath(entry, basename)
-		f, err := c.DataStore.Writer(p)
+		var buf bytes.Buffer
+		h := sha256.New()
+		_, err = io.Copy(io.MultiWriter(&buf, h), resp)
 		if err != nil {
-			return err
+			return "", "", err
 		}
-		defer f.Close()
-
-		_, err = io.Copy(f, resp)
+		err = writeFile(c.DataStore, p, buf.Bytes())
 		if err != nil {
-			return err
+			return "", "", err
 		}
 		fmt.Println("saved", p)
-		return nil
+		return p, hex.EncodeToString(h.Sum(nil)), nil
 	}
+
+	// Remove duplicated URLs not to write the same file concurrently
+	var srcs []string
+	seen := make(map[string]bool)
 	for _, u := range urls {
-		err := download(ctx, u)
-		if err != nil {
-			return fmt.Errorf("unable download %s: %w", u, err)
+		if !seen[u] {
+			seen[u] = true
+			srcs = append(srcs, u)
 		}
 	}
-	return nil
+
+	results := make([]imageResult, len(srcs))
+	g, ctx := newGroup(ctx, c.ImageJobs)
+	for i, u := range srcs {
+		i, u := i, u
+		g.Go(func() error {
+			p, hash, err := download(ctx, u)
+			if err != nil {
+				err = fmt.Errorf("unable download %s: %w", u, err)
+			}
+			results[i] = imageResult{URL: u, Path: p, Hash: hash, Err: err}
+			if c.KeepGoing {
+				return nil
+			}
+			return err
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
-func (c Crawler) listAllEntries(ctx context.Context, fn func(ctx context.Context, entry blog.Entry) error) error {
-	input := blog.ListEntriesInput{
+// listAllEntries calls fn with the entries from the page.  The onPage is
+// called with the page token at the beginning of each following page, after
+// the entries in the previous pages are passed to fn.
+func (c Crawler) listAllEntries(ctx context.Context, page string, onPage func(ctx context.Context, page string) error, fn func(ctx context.Context, entry blog.Entry) error) error {
+	it := c.BlogClient.Entries(ctx, blog.EntriesInput{
 		HatenaID: c.HatenaID,
 		BlogID:   c.BlogID,
-	}
-	for {
-		feed, err := c.BlogClient.ListEntries(ctx, input)
-		if err != nil {
-			return fmt.Errorf("unable to list blog entries: %w", err)
-		}
-
-		for _, entry := range feed.Entries {
-			err := fn(ctx, entry)
+		Page:     page,
+		Delay:    1 * time.Second,
+	})
+	for it.Next() {
+		if it.Page() != page {
+			page = it.Page()
+			err := onPage(ctx, page)
 			if err != nil {
-				return fmt.Errorf("unable process %s (%s): %w", entry.Path(), entry.ID, err)
+				return err
 			}
 		}
 
-		next := feed.NextPage()
-		if len(next) == 0 {
-			break
+		entry := it.Entry()
+		err := fn(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("unable process %s (%s): %w", entry.Path(), entry.ID, err)
 		}
-		input.Page = next
-
-		time.Sleep(1 * time.Second)
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("unable to list blog entries: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,633 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/blog"
+	"github.com/ueokande/hatenactl/pkg/hatena/hatenatest"
+	"golang.org/x/net/html"
+)
+
+// newTestCrawler returns a crawler of the blog on a new fake server, which
+// saves files into the store.  The server is closed at the end of the test.
+func newTestCrawler(t *testing.T, store DataStore) (*Crawler, *hatenatest.Server) {
+	server := hatenatest.NewServer("ueokande", "ueokande.hatenablog.com")
+	t.Cleanup(server.Close)
+
+	c := &Crawler{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		BlogClient: &blog.Client{
+			HTTPClient: server.Client(),
+			BaseURL:    server.URL,
+		},
+		DataStore: store,
+		Path:      &Path{},
+	}
+	return c, server
+}
+
+func TestCrawlerStart(t *testing.T) {
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+
+	imageURL := server.AddFile("/images/foobar.png", "image/png", []byte("\x89PNG"))
+	server.AddEntry(blog.Entry{
+		Title:      "Greeting",
+		Published:  time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC),
+		Categories: []blog.Category{{Term: "Hobby"}},
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello, world</p><img src="` + imageURL + `"/>`,
+		},
+	})
+
+	c.Filters = []Filter{&TitleFilter{}, &ImagePathFilter{}}
+	err := c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{
+		"archive/2020/index.html",
+		"category/Hobby/index.html",
+		"checkpoint.json",
+		"entry/2020/03/01/123456/foobar.png",
+		"entry/2020/03/01/123456/index.html",
+		"index.html",
+		"manifest.json",
+	}
+	if !reflect.DeepEqual(store.Paths(), paths) {
+		t.Errorf("%v != %v", store.Paths(), paths)
+	}
+
+	r, err := store.Reader("entry/2020/03/01/123456/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<h1>Greeting</h1>`) ||
+		!strings.Contains(string(content), `<img src="foobar.png"`) {
+		t.Errorf("unexpected entry: %s", content)
+	}
+}
+
+func TestCrawlerStartImageNameCollision(t *testing.T) {
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+
+	imageURL1 := server.AddFile("/images/1/foobar.png", "image/png", []byte("image 1"))
+	imageURL2 := server.AddFile("/images/2/foobar.png", "image/png", []byte("image 2"))
+	server.AddEntry(blog.Entry{
+		Title:     "Greeting",
+		Published: time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC),
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<img src="` + imageURL1 + `"/><img src="` + imageURL2 + `"/>`,
+		},
+	})
+
+	c.Filters = []Filter{&ImagePathFilter{}}
+	c.ImageJobs = 2
+	err := c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The images with the same base name are saved as different files
+	content := string(store.files["entry/2020/03/01/123456/index.html"])
+	names := imageFileNames([]string{imageURL1, imageURL2})
+	for u, data := range map[string]string{imageURL1: "image 1", imageURL2: "image 2"} {
+		p := "entry/2020/03/01/123456/" + names[u]
+		if string(store.files[p]) != data {
+			t.Errorf("%s: %q != %q", p, store.files[p], data)
+		}
+		if !strings.Contains(content, `src="`+names[u]+`"`) {
+			t.Errorf("no image %s in the entry: %s", names[u], content)
+		}
+	}
+	if names[imageURL1] == names[imageURL2] {
+		t.Errorf("the same file name %q", names[imageURL1])
+	}
+}
+
+// failingTransport is a RoundTripper failing all requests.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("unexpected request")
+}
+
+func TestCrawlerStartIncremental(t *testing.T) {
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+
+	now := time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC)
+	server.Now = func() time.Time { return now }
+
+	imageURL := server.AddFile("/images/foobar.png", "image/png", []byte("\x89PNG"))
+	entry := server.AddEntry(blog.Entry{
+		Title:     "Greeting",
+		Published: now,
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello, world</p><img src="` + imageURL + `"/>`,
+		},
+	})
+	server.AddEntry(blog.Entry{
+		Title:     "Farewell",
+		Published: now.Add(24 * time.Hour),
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Goodbye</p>`,
+		},
+	})
+
+	c.Filters = []Filter{&TitleFilter{}}
+	err := c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unchanged entries and images are not downloaded
+	c.Downloader = &Downloader{HTTPClient: &http.Client{Transport: failingTransport{}}}
+	err = c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The edited entry is saved again
+	now = now.Add(time.Hour)
+	id, err := entry.EntryID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.BlogClient.UpdateEntry(context.Background(), blog.UpdateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  id.Entry,
+		Entry:    blog.Entry{Title: "Greeting again", Content: blog.Content{Type: "text/x-markdown", Content: "Hello again"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := store.Reader("entry/2020/03/01/123456/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<h1>Greeting again</h1>`) {
+		t.Errorf("unexpected entry: %s", content)
+	}
+
+	// The index pages contain all entries
+	r, err = store.Reader("archive/2020/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Greeting again") || !strings.Contains(string(content), "Farewell") {
+		t.Errorf("unexpected archive: %s", content)
+	}
+
+	// All entries are saved again on changing the options
+	store.files["entry/2020/03/02/123456/index.html"] = []byte("stale")
+	c.Downloader = nil
+	c.Filters = []Filter{&TitleFilter{}, &ImagePathFilter{}}
+	err = c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = store.Reader("entry/2020/03/02/123456/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<h1>Farewell</h1>`) {
+		t.Errorf("unexpected entry: %s", content)
+	}
+}
+
+func TestCrawlerStartConcurrent(t *testing.T) {
+	c, server := newTestCrawler(t, nil)
+	c.Downloader = &Downloader{MaxConnsPerHost: 2}
+	c.Filters = []Filter{&TitleFilter{}, &ImagePathFilter{}}
+
+	published := time.Date(2019, 12, 25, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		imageURL := server.AddFile(fmt.Sprintf("/images/%d.png", i), "image/png", []byte(fmt.Sprintf("image %d", i)))
+		server.AddEntry(blog.Entry{
+			Title:      fmt.Sprintf("Entry %d", i),
+			Published:  published.Add(time.Duration(i) * 24 * time.Hour),
+			Categories: []blog.Category{{Term: "Diary"}},
+			FormattedContent: blog.Content{
+				Type:    "text/html",
+				Content: `<p>Hello</p><img src="` + imageURL + `"/><img src="` + imageURL + `"/>`,
+			},
+		})
+	}
+
+	crawl := func(jobs int) *MemoryStore {
+		store := NewMemoryStore()
+		c.DataStore = store
+		c.Jobs = jobs
+		c.ImageJobs = jobs
+		err := c.Start(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	// The output is the same regardless of the order of the completion
+	expected := crawl(1)
+	actual := crawl(8)
+	if !reflect.DeepEqual(actual.Paths(), expected.Paths()) {
+		t.Fatalf("%v != %v", actual.Paths(), expected.Paths())
+	}
+	for _, p := range expected.Paths() {
+		if !reflect.DeepEqual(expected.files[p], actual.files[p]) {
+			t.Errorf("%s differs: %s != %s", p, actual.files[p], expected.files[p])
+		}
+	}
+}
+
+func TestCrawlerStartKeepGoing(t *testing.T) {
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+
+	published := time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC)
+	server.AddEntry(blog.Entry{
+		Title:     "Broken image",
+		Published: published,
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello</p><img src="` + server.URL + `/images/missing.png"/>`,
+		},
+	})
+	server.AddEntry(blog.Entry{
+		Title:     "Markdown",
+		Published: published.Add(24 * time.Hour),
+		FormattedContent: blog.Content{
+			Type:    "text/x-markdown",
+			Content: `# Hello`,
+		},
+	})
+	server.AddEntry(blog.Entry{
+		Title:     "Greeting",
+		Published: published.Add(48 * time.Hour),
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello, world</p>`,
+		},
+	})
+
+	err := c.Start(context.Background())
+	if err == nil {
+		t.Fatal("failures should abort the crawl")
+	}
+	var partialErr *PartialError
+	if errors.As(err, &partialErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.KeepGoing = true
+	err = c.Start(context.Background())
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partialErr.Entries != 3 {
+		t.Errorf("%d != %d", partialErr.Entries, 3)
+	}
+	if len(partialErr.Failures) != 2 {
+		t.Fatalf("unexpected failures: %+v", partialErr.Failures)
+	}
+	if f := partialErr.Failures[0]; f.Kind != FailureAsset || f.URL != server.URL+"/images/missing.png" {
+		t.Errorf("unexpected failure: %+v", f)
+	}
+	if f := partialErr.Failures[1]; f.Kind != FailureEntry || f.Path != "/entry/2020/03/02/123456" {
+		t.Errorf("unexpected failure: %+v", f)
+	}
+
+	for _, p := range []string{
+		"entry/2020/03/01/123456/index.html",
+		"entry/2020/03/03/123456/index.html",
+		"index.html",
+	} {
+		if _, err := store.Reader(p); err != nil {
+			t.Errorf("%s not saved: %v", p, err)
+		}
+	}
+
+	// The failed entry is not linked from the index pages
+	archive := string(store.files["archive/2020/index.html"])
+	if !strings.Contains(archive, "Broken image") || !strings.Contains(archive, "Greeting") || strings.Contains(archive, "Markdown") {
+		t.Errorf("unexpected archive: %s", archive)
+	}
+
+	m, err := ReadManifest(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 2 {
+		t.Errorf("%d != %d", len(m.Entries), 2)
+	}
+	for _, e := range m.Entries {
+		partial := e.Files[0] == "/entry/2020/03/01/123456/index.html"
+		if e.Partial != partial {
+			t.Errorf("%v != %v: %+v", e.Partial, partial, e)
+		}
+	}
+}
+
+// failingFilter is a Filter failing on all entries.
+type failingFilter struct{}
+
+func (failingFilter) Process(blog.Entry, *html.Node) error {
+	return errors.New("broken filter")
+}
+
+// closeFailingStore is a DataStore failing to close writers of the path.
+type closeFailingStore struct {
+	*MemoryStore
+	path string
+}
+
+func (s *closeFailingStore) Writer(path string) (io.WriteCloser, error) {
+	if path == s.path {
+		return &bufferedWriter{onClose: func([]byte) error {
+			return errors.New("disk full")
+		}}, nil
+	}
+	return s.MemoryStore.Writer(path)
+}
+
+func TestCrawlerStartWriteFailure(t *testing.T) {
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+
+	server.AddEntry(blog.Entry{
+		Title:     "Greeting",
+		Published: time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC),
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello, world</p>`,
+		},
+	})
+
+	// The entry is not stored if the rendering fails
+	c.Filters = []Filter{failingFilter{}}
+	c.KeepGoing = true
+	err := c.Start(context.Background())
+	var partialErr *PartialError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range []string{"entry/2020/03/01/123456/index.html", "archive/2020/index.html"} {
+		if _, err := store.Reader(p); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s must not be stored: %v", p, err)
+		}
+	}
+
+	// The error on closing the file fails the crawl
+	c.DataStore = &closeFailingStore{MemoryStore: NewMemoryStore(), path: "index.html"}
+	c.Filters = nil
+	c.KeepGoing = false
+	err = c.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// interruptingStore is a DataStore calling cancel on writing the path.
+type interruptingStore struct {
+	*MemoryStore
+	path   string
+	cancel func()
+}
+
+func (s *interruptingStore) Writer(path string) (io.WriteCloser, error) {
+	if slashPath(path) == s.path {
+		defer s.cancel()
+	}
+	return s.MemoryStore.Writer(path)
+}
+
+func TestCrawlerStartResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := &interruptingStore{MemoryStore: NewMemoryStore(), path: CheckpointPath, cancel: cancel}
+	c, server := newTestCrawler(t, store)
+	c.Filters = []Filter{&TitleFilter{}}
+	c.KeepGoing = true
+	server.PageSize = 2
+
+	published := time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		typ := "text/html"
+		if i == 3 {
+			// The failure before the checkpoint
+			typ = "text/x-markdown"
+		}
+		server.AddEntry(blog.Entry{
+			Title:     fmt.Sprintf("Entry %d", i),
+			Published: published.Add(time.Duration(i) * 24 * time.Hour),
+			FormattedContent: blog.Content{
+				Type:    typ,
+				Content: fmt.Sprintf("<p>Hello %d</p>", i),
+			},
+		})
+	}
+
+	err := c.Start(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp, err := ReadCheckpoint(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Completed || len(cp.Page) == 0 || len(cp.Entries) != 2 {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+	if cp.Entries[0].Title != "Entry 4" || len(cp.Entries[0].FormattedContent.Content) != 0 {
+		t.Errorf("unexpected entry in the checkpoint: %+v", cp.Entries[0])
+	}
+	if len(cp.Failures) != 1 {
+		t.Errorf("unexpected failures in the checkpoint: %+v", cp.Failures)
+	}
+
+	c.DataStore = store.MemoryStore
+	c.Resume = true
+	err = c.Start(context.Background())
+	var partialErr *PartialError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partialErr.Entries != 5 {
+		t.Errorf("%d != %d", partialErr.Entries, 5)
+	}
+	if len(partialErr.Failures) != 1 || partialErr.Failures[0].Path != "/entry/2020/03/04/123456" {
+		t.Errorf("unexpected failures: %+v", partialErr.Failures)
+	}
+
+	cp, err = ReadCheckpoint(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Completed {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+	m, err := ReadManifest(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 4 {
+		t.Errorf("%d != %d", len(m.Entries), 4)
+	}
+
+	r, err := store.Reader("archive/2020/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		title := fmt.Sprintf("Entry %d", i)
+		if linked := strings.Contains(string(content), title); linked != (i != 3) {
+			t.Errorf("%s linked %v: %s", title, linked, content)
+		}
+	}
+}
+
+// cancelingTransport is a RoundTripper calling cancel on requests, and
+// failing them on the cancellation.
+type cancelingTransport struct {
+	cancel func()
+}
+
+func (t cancelingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.cancel()
+	<-r.Context().Done()
+	return nil, r.Context().Err()
+}
+
+func TestCrawlerStartCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryStore()
+	c, server := newTestCrawler(t, store)
+	c.Downloader = &Downloader{HTTPClient: &http.Client{Transport: cancelingTransport{cancel: cancel}}}
+	c.KeepGoing = true
+
+	imageURL := server.AddFile("/images/image.png", "image/png", []byte("\x89PNG"))
+	published := time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC)
+	server.AddEntry(blog.Entry{
+		Title:     "Greeting",
+		Published: published,
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello, world</p>`,
+		},
+	})
+	server.AddEntry(blog.Entry{
+		Title:     "Image",
+		Published: published.Add(24 * time.Hour),
+		FormattedContent: blog.Content{
+			Type:    "text/html",
+			Content: `<p>Hello</p><img src="` + imageURL + `"/>`,
+		},
+	})
+
+	// The cancellation is not a failure of the entry
+	err := c.Start(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var partialErr *PartialError
+	if errors.As(err, &partialErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.files["entry/2020/03/02/123456/index.html"]; ok {
+		t.Error("the canceled entry saved")
+	}
+	cp, err := ReadCheckpoint(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.Completed || len(cp.Failures) != 0 {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+
+	// The interrupted crawl is resumed to the end
+	c.Downloader = nil
+	c.Resume = true
+	err = c.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err = ReadCheckpoint(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Completed {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+	for _, p := range []string{
+		"entry/2020/03/01/123456/index.html",
+		"entry/2020/03/02/123456/index.html",
+		"index.html",
+	} {
+		if _, err := store.Reader(p); err != nil {
+			t.Errorf("%s not saved: %v", p, err)
+		}
+	}
+
+	// The crawl interrupted after saving the last entry is not completed
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	istore := &interruptingStore{MemoryStore: NewMemoryStore(), path: "entry/2020/03/01/123456/index.html", cancel: cancel}
+	c.DataStore = istore
+	c.Resume = false
+	err = c.Start(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err = ReadCheckpoint(istore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.Completed {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Kinds of the failures
+const (
+	FailureEntry = "entry"
+	FailureAsset = "asset"
+	FailureIndex = "index"
+)
+
+// A Failure is a failure to save an entry, an asset in the entry or an index
+// page.
+type Failure struct {
+	// Kind is a kind of the failure (entry | asset | index)
+	Kind string `json:"kind"`
+	// EntryID is an ID of the entry.  It is empty on the index page.
+	EntryID string `json:"entry_id,omitempty"`
+	// Path is a path of the entry or the index page
+	Path string `json:"path"`
+	// URL is an URL of the asset
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error"`
+}
+
+// PartialError is returned by Crawler.Start in the KeepGoing mode if some
+// entries, assets or index pages are not saved.
+type PartialError struct {
+	// Entries is the number of the entries in the blog
+	Entries  int       `json:"entries"`
+	Failures []Failure `json:"failures"`
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("crawl finished with %d failures", len(e.Failures))
+}
+
+// Count returns the number of the failures of the kind.
+func (e *PartialError) Count(kind string) int {
+	n := 0
+	for _, f := range e.Failures {
+		if f.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// failureList is a list of failures safe for concurrent use.
+type failureList struct {
+	mu       sync.Mutex
+	failures []Failure
+}
+
+func (l *failureList) add(f Failure) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures = append(l.failures, f)
+}
+
+// entries returns a set of IDs of the entries failed to save.
+func (l *failureList) entries() map[string]bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ids := make(map[string]bool)
+	for _, f := range l.failures {
+		if f.Kind == FailureEntry {
+			ids[f.EntryID] = true
+		}
+	}
+	return ids
+}
+
+// sorted returns the failures sorted by the kind, the path and the URL, to
+// be independent of the order of the completion.
+func (l *failureList) sorted() []Failure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	failures := append([]Failure(nil), l.failures...)
+	sort.Slice(failures, func(i, j int) bool {
+		a, b := failures[i], failures[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.URL < b.URL
+	})
+	return failures
+}
@@ -1,10 +1,7 @@
 package crawler
 
 import (
-	"crypto/sha1"
-	"fmt"
 	"net/url"
-	"path"
 	"time"
 
 	"github.com/ueokande/hatenactl/pkg/hatena/blog"
@@ -110,6 +107,8 @@ func (f CategoryFilter) Process(entry blog.Entry, root *html.Node) error {
 type ImagePathFilter struct{}
 
 func (f ImagePathFilter) Process(entry blog.Entry, root *html.Node) error {
+	urlext := ImageURLExtractor{}
+	names := imageFileNames(urlext.ExtractImageURLs(root))
 	tr := &Transformer{
 		Func: func(node *html.Node) (*html.Node, error) {
 			if node.Type != html.ElementNode {
@@ -120,15 +119,11 @@ func (f ImagePathFilter) Process(entry blog.Entry, root *html.Node) error {
 				for i, attr := range node.Attr {
 					if attr.Key == "src" {
 						src = attr.Val
-						u, err := url.Parse(attr.Val)
+						_, err := url.Parse(attr.Val)
 						if err != nil {
 							return nil, err
 						}
-						basename := path.Base(u.Path)
-						if len(basename) > 127 {
-							basename = fmt.Sprintf("%X", sha1.Sum([]byte(basename)))
-						}
-						node.Attr[i].Val = basename
+						node.Attr[i].Val = names[attr.Val]
 					}
 				}
 				if len(src) > 0 {
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+)
+
+// group runs functions on a bounded number of goroutines.  The context of
+// the group is cancelled on the first error.
+type group struct {
+	ctx    context.Context
+	cancel func()
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// newGroup returns a new group running at most n functions at a time, and
+// the context cancelled on the first error.
+func newGroup(ctx context.Context, n int) (*group, context.Context) {
+	if n < 1 {
+		n = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, n),
+	}, ctx
+}
+
+// Go runs the fn on a new goroutine.  It blocks until a goroutine is
+// available.  The fn is not run if the context is done.
+func (g *group) Go(fn func() error) {
+	select {
+	case g.sem <- struct{}{}:
+	case <-g.ctx.Done():
+		g.setErr(g.ctx.Err())
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+
+		err := fn()
+		if err != nil {
+			g.setErr(err)
+		}
+	}()
+}
+
+// Err returns the first error of the functions.
+func (g *group) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Flush waits for the running functions, and returns the first error.  The
+// group is still available after Flush.
+func (g *group) Flush() error {
+	g.wg.Wait()
+	return g.Err()
+}
+
+// Wait waits for all functions, and returns the first error.
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.Err()
+}
+
+func (g *group) setErr(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err == nil {
+		g.err = err
+		g.cancel()
+	}
+}
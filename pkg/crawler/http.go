@@ -4,25 +4,89 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
 )
 
 type Downloader struct {
 	HTTPClient *http.Client
+
+	// MaxConnsPerHost limits the number of concurrent downloads from each
+	// host.  No limit if zero.
+	MaxConnsPerHost int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
 }
 
+// Download starts downloading the URL, and returns the response body.  The
+// connection to the host is counted until the body is closed.
 func (c *Downloader) Download(ctx context.Context, url string) (io.ReadCloser, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	release, err := c.acquire(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
 	httpClient := c.HTTPClient
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
+		release()
 		return nil, err
 	}
-	return resp.Body, nil
+	if resp.StatusCode != 200 {
+		defer release()
+		defer resp.Body.Close()
+		return nil, apierror.FromResponse(resp)
+	}
+	return &releaseReadCloser{ReadCloser: resp.Body, release: release}, nil
+}
+
+// acquire waits for a connection to the host of the URL to be available.  It
+// returns a function to release the connection.
+func (c *Downloader) acquire(ctx context.Context, u *url.URL) (func(), error) {
+	if c.MaxConnsPerHost <= 0 {
+		return func() {}, nil
+	}
+
+	c.mu.Lock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]chan struct{})
+	}
+	sem, ok := c.hosts[u.Host]
+	if !ok {
+		sem = make(chan struct{}, c.MaxConnsPerHost)
+		c.hosts[u.Host] = sem
+	}
+	c.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-sem })
+	}, nil
+}
+
+// releaseReadCloser is a ReadCloser calling release on Close.
+type releaseReadCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseReadCloser) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
 }
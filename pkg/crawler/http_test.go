@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloaderMaxConnsPerHost(t *testing.T) {
+	var mu sync.Mutex
+	active, max := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > max {
+			max = active
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	d := &Downloader{HTTPClient: server.Client(), MaxConnsPerHost: 2}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := d.Download(context.Background(), server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer body.Close()
+			_, err = ioutil.ReadAll(body)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if max > 2 {
+		t.Errorf("%d concurrent downloads exceeds the limit %d", max, 2)
+	}
+}
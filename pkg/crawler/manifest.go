@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ManifestPath is a path to the manifest in the data store.
+const ManifestPath = "manifest.json"
+
+const manifestVersion = 1
+
+// A Manifest is a record of the entries saved by the last crawl.  The
+// crawler skips entries not edited since the last crawl.
+type Manifest struct {
+	Version int `json:"version"`
+	// Options is a fingerprint of the options rendering the pages.  All
+	// entries are saved again if the options are changed.
+	Options string `json:"options,omitempty"`
+	// Entries are saved entries by the entry ID
+	Entries map[string]ManifestEntry `json:"entries"`
+}
+
+// A ManifestEntry is a record of the saved entry.
+type ManifestEntry struct {
+	// Edited is the edited time of the saved entry
+	Edited time.Time `json:"edited"`
+	// Files are paths to the files of the entry in the data store
+	Files []string `json:"files"`
+	// Images are SHA-256 hashes of the downloaded images by the URL
+	Images map[string]string `json:"images,omitempty"`
+	// Partial is true if some images of the entry are failed to download.
+	// The partial entry is saved again on the next crawl.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// NewManifest returns a new empty manifest.
+func NewManifest() *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+		Entries: make(map[string]ManifestEntry),
+	}
+}
+
+// ReadManifest reads the manifest from the data store.  It returns an empty
+// manifest if the store is not a DataReader or the manifest is not stored.
+func ReadManifest(s DataStore) (*Manifest, error) {
+	reader, ok := s.(DataReader)
+	if !ok {
+		return NewManifest(), nil
+	}
+	r, err := reader.Reader(ManifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewManifest(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var m Manifest
+	err = json.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the manifest: %w", err)
+	}
+	if m.Version != manifestVersion {
+		return NewManifest(), nil
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]ManifestEntry)
+	}
+	return &m, nil
+}
+
+// WriteManifest writes the manifest to the data store.
+func WriteManifest(s DataStore, m *Manifest) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(m)
+	if err != nil {
+		return err
+	}
+	return writeFile(s, ManifestPath, buf.Bytes())
+}
+
+// storedHash returns a SHA-256 hash of the file in the data store.  It
+// returns empty string if the file is not readable.
+func storedHash(s DataStore, path string) string {
+	reader, ok := s.(DataReader)
+	if !ok {
+		return ""
+	}
+	r, err := reader.Reader(path)
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stored returns true if all files are in the data store.
+func stored(s DataStore, paths []string) bool {
+	reader, ok := s.(DataReader)
+	if !ok {
+		return false
+	}
+	for _, p := range paths {
+		r, err := reader.Reader(p)
+		if err != nil {
+			return false
+		}
+		r.Close()
+	}
+	return true
+}
@@ -1,10 +1,13 @@
 package crawler
 
 import (
+	"crypto/sha1"
+	"fmt"
 	"net/url"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/ueokande/hatenactl/pkg/hatena/blog"
 )
@@ -53,3 +56,35 @@ func (p Path) ArchiveUrlPath(year int) string {
 func (p Path) ArchiveFilePath(year int) string {
 	return filepath.Join("archive", strconv.FormatInt(int64(year), 10), "index.html")
 }
+
+// imageFileNames returns file names of the images in the entry by the URL.
+// The name is the base name of the URL, or its SHA-1 hash if too long.  The
+// URL whose name is already taken by the former URL gets the name with a
+// short hash of the URL, such as "foobar-1a2b3c4d.png".  The invalid URLs
+// are not included.
+func imageFileNames(urls []string) map[string]string {
+	names := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, src := range urls {
+		if _, ok := names[src]; ok {
+			continue
+		}
+		u, err := url.Parse(src)
+		if err != nil {
+			continue
+		}
+		name := path.Base(u.Path)
+		// convert long file name
+		if len(name) > 127 {
+			name = fmt.Sprintf("%X", sha1.Sum([]byte(name)))
+		}
+		if taken[name] {
+			ext := path.Ext(name)
+			hash := sha1.Sum([]byte(src))
+			name = fmt.Sprintf("%s-%x%s", strings.TrimSuffix(name, ext), hash[:4], ext)
+		}
+		taken[name] = true
+		names[src] = name
+	}
+	return names
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"sync"
+	"time"
+)
+
+// TarStore is a DataStore writing files into a gzip-compressed tar archive.
+// Each file is buffered in memory until the writer is closed, then appended
+// to the archive.
+type TarStore struct {
+	mu sync.Mutex
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+// NewTarStore returns a new TarStore writing the archive to w.
+func NewTarStore(w io.Writer) *TarStore {
+	gw := gzip.NewWriter(w)
+	return &TarStore{gw: gw, tw: tar.NewWriter(gw)}
+}
+
+func (s *TarStore) Writer(path string) (io.WriteCloser, error) {
+	return &bufferedWriter{onClose: func(content []byte) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		err := s.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     slashPath(path),
+			Size:     int64(len(content)),
+			Mode:     0644,
+			ModTime:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = s.tw.Write(content)
+		return err
+	}}, nil
+}
+
+// Close writes the end of the archive.  It does not close the underlying
+// writer.
+func (s *TarStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.tw.Close()
+	if err != nil {
+		return err
+	}
+	return s.gw.Close()
+}
+
+// ZipStore is a DataStore writing files into a zip archive.  Each file is
+// buffered in memory until the writer is closed, then appended to the
+// archive.
+type ZipStore struct {
+	mu sync.Mutex
+	zw *zip.Writer
+}
+
+// NewZipStore returns a new ZipStore writing the archive to w.
+func NewZipStore(w io.Writer) *ZipStore {
+	return &ZipStore{zw: zip.NewWriter(w)}
+}
+
+func (s *ZipStore) Writer(path string) (io.WriteCloser, error) {
+	return &bufferedWriter{onClose: func(content []byte) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w, err := s.zw.CreateHeader(&zip.FileHeader{
+			Name:     slashPath(path),
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}}, nil
+}
+
+// Close writes the central directory of the archive.  It does not close the
+// underlying writer.
+func (s *ZipStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.zw.Close()
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a DataStore keeping files in memory.
+type MemoryStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Writer(path string) (io.WriteCloser, error) {
+	return &bufferedWriter{onClose: func(content []byte) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.files[slashPath(path)] = content
+		return nil
+	}}, nil
+}
+
+func (s *MemoryStore) Reader(path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.files[slashPath(path)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Paths returns the sorted slash-separated paths of the stored files.
+func (s *MemoryStore) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var paths []string
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
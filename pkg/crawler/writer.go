@@ -1,26 +1,222 @@
 package crawler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
-type DataStore struct {
+// A DataStore stores files generated by the crawler.  The DataStore is safe
+// for concurrent use.
+type DataStore interface {
+	// Writer returns a writer of the file at the path.  The file is stored
+	// when the writer is closed.
+	Writer(path string) (io.WriteCloser, error)
+
+	// Close flushes the files and closes the store.
+	Close() error
+}
+
+// A DataReader is a DataStore which can read the stored files.  The Reader
+// returns an error satisfying errors.Is(err, os.ErrNotExist) if the file
+// is not stored.
+type DataReader interface {
+	DataStore
+
+	Reader(path string) (io.ReadCloser, error)
+}
+
+// An Aborter is a DataStore which can discard the stored files on failures.
+type Aborter interface {
+	DataStore
+
+	// Abort closes the store discarding the files written since opened.
+	Abort() error
+}
+
+// OpenDataStore opens a DataStore by the target in the form of
+// "<kind>:<path>".  The kind is one of the following:
+//
+//    dir:  files in the directory
+//    tar:  a gzip-compressed tar archive
+//    zip:  a zip archive
+//
+// The target without the kind is treated as a directory.  The archive is
+// written to a temporary file, and replaces the path on Close.  The DataStore
+// of the archive is an Aborter keeping the existing archive on Abort.
+func OpenDataStore(target string) (DataStore, error) {
+	kind, path := "dir", target
+	if i := strings.Index(target, ":"); i > 1 {
+		kind, path = target[:i], target[i+1:]
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("no path in the output %q", target)
+	}
+
+	switch kind {
+	case "dir":
+		return &DirStore{Directory: path}, nil
+	case "tar", "zip":
+		dir := filepath.Dir(path)
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return nil, err
+		}
+		f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+"-")
+		if err != nil {
+			return nil, err
+		}
+		if kind == "tar" {
+			return &fileStore{DataStore: NewTarStore(f), f: f, path: path}, nil
+		}
+		return &fileStore{DataStore: NewZipStore(f), f: f, path: path}, nil
+	}
+	return nil, fmt.Errorf("unknown kind of the output %q (dir | tar | zip)", kind)
+}
+
+// fileStore is a DataStore writing to the temporary file, and renaming it to
+// the path on Close.
+type fileStore struct {
+	DataStore
+	f    *os.File
+	path string
+}
+
+func (s *fileStore) Close() error {
+	err := s.DataStore.Close()
+	if err != nil {
+		s.Abort()
+		return err
+	}
+	err = s.f.Close()
+	if err != nil {
+		os.Remove(s.f.Name())
+		return err
+	}
+	err = os.Chmod(s.f.Name(), 0644)
+	if err == nil {
+		err = os.Rename(s.f.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(s.f.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Abort() error {
+	err := s.f.Close()
+	rerr := os.Remove(s.f.Name())
+	if err != nil {
+		return err
+	}
+	return rerr
+}
+
+// DirStore is a DataStore storing files under the directory.  Each file is
+// written to a temporary file, and replaces the file on Close, so that the
+// interrupted write does not break the existing file.
+type DirStore struct {
 	Directory string
 }
 
-func (d DataStore) Writer(path string) (io.WriteCloser, error) {
-	path = filepath.Join(d.Directory, path)
+func (d *DirStore) Writer(path string) (io.WriteCloser, error) {
+	path = filepath.Join(d.Directory, filepath.FromSlash(path))
 
 	dir := filepath.Dir(path)
 	err := os.MkdirAll(dir, 0755)
 	if err != nil {
 		return nil, err
 	}
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+"-")
 	if err != nil {
 		return nil, err
 	}
-	return f, nil
+	return &atomicFile{f: f, path: path}, nil
+}
+
+func (d *DirStore) Reader(path string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.Directory, filepath.FromSlash(path)))
+}
+
+func (d *DirStore) Close() error {
+	return nil
+}
+
+// writeFile stores the content as the file at the path in the data store.
+// The content is generated in memory in advance, so that nothing is stored
+// if the generation fails.
+func writeFile(s DataStore, path string, content []byte) error {
+	w, err := s.Writer(path)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// atomicFile is a temporary file renamed to the path on Close.  The file is
+// removed instead if any write failed.
+type atomicFile struct {
+	f    *os.File
+	path string
+	err  error
+}
+
+func (w *atomicFile) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *atomicFile) Close() error {
+	err := w.f.Close()
+	if w.err != nil {
+		err = w.err
+	}
+	if err == nil {
+		err = os.Chmod(w.f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(w.f.Name(), w.path)
+	}
+	if err != nil {
+		os.Remove(w.f.Name())
+		return err
+	}
+	return nil
+}
+
+// slashPath returns a clean slash-separated path relative to the root of
+// the store, such as "entry/2020/03/01/123456/index.html".
+func slashPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
+}
+
+// bufferedWriter is a writer buffering the content until closed.  The
+// content is passed to the onClose.
+type bufferedWriter struct {
+	bytes.Buffer
+	onClose func(content []byte) error
+	closed  bool
+}
+
+func (w *bufferedWriter) Close() error {
+	if w.closed {
+		return errors.New("writer already closed")
+	}
+	w.closed = true
+	return w.onClose(w.Bytes())
 }
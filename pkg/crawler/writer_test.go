@@ -0,0 +1,248 @@
+package crawler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testFiles = map[string]string{
+	"index.html":                "<h1>blog</h1>",
+	"entry/2020/03/01/123456/a": "hello",
+}
+
+func writeTestFiles(t *testing.T, s DataStore) {
+	for p, content := range testFiles {
+		w, err := s.Writer(filepath.FromSlash(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.WriteString(w, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFiles(t *testing.T, s DataReader) map[string]string {
+	files := make(map[string]string)
+	for p := range testFiles {
+		r, err := s.Reader(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[p] = string(content)
+	}
+	_, err := s.Reader("missing.html")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	return files
+}
+
+func testDirStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &DirStore{Directory: dir}
+	writeTestFiles(t, s)
+	if files := readTestFiles(t, s); !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("%v != %v", files, testFiles)
+	}
+
+	// The file is not replaced until the writer is closed
+	w, err := s.Writer("index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "<h1>new blog</h1>")
+	if files := readTestFiles(t, s); !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("%v != %v", files, testFiles)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Errorf("temporary files are left: %d files", len(infos))
+	}
+}
+
+func testMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	writeTestFiles(t, s)
+	if files := readTestFiles(t, s); !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("%v != %v", files, testFiles)
+	}
+	if paths := s.Paths(); !reflect.DeepEqual(paths, []string{"entry/2020/03/01/123456/a", "index.html"}) {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+}
+
+func testTarStore(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestFiles(t, NewTarStore(&buf))
+
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(content)
+	}
+	if !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("%v != %v", files, testFiles)
+	}
+}
+
+func testZipStore(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestFiles(t, NewZipStore(&buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	if !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("%v != %v", files, testFiles)
+	}
+}
+
+func testOpenDataStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, target := range []string{
+		"dir:" + filepath.Join(dir, "out"),
+		"tar:" + filepath.Join(dir, "out.tar.gz"),
+		"zip:" + filepath.Join(dir, "out.zip"),
+	} {
+		s, err := OpenDataStore(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFiles(t, s)
+	}
+	var names []string
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"out", "out.tar.gz", "out.zip"}) {
+		t.Errorf("unexpected files: %v", names)
+	}
+
+	// The existing archive is kept on Abort
+	for _, target := range []string{
+		"tar:" + filepath.Join(dir, "out.tar.gz"),
+		"zip:" + filepath.Join(dir, "out.zip"),
+	} {
+		path := target[4:]
+		before, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := OpenDataStore(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := s.Writer("index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, "<h1>broken</h1>")
+		w.Close()
+		err = s.(Aborter).Abort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		after, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(before, after) {
+			t.Errorf("%s is modified on Abort", path)
+		}
+	}
+	infos, err = ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 3 {
+		t.Errorf("temporary files are left: %d files", len(infos))
+	}
+
+	_, err = OpenDataStore("rar:" + filepath.Join(dir, "out.rar"))
+	if err == nil {
+		t.Error("unknown kind should be an error")
+	}
+}
+
+func TestDataStore(t *testing.T) {
+	t.Run("DirStore", testDirStore)
+	t.Run("MemoryStore", testMemoryStore)
+	t.Run("TarStore", testTarStore)
+	t.Run("ZipStore", testZipStore)
+	t.Run("OpenDataStore", testOpenDataStore)
+}
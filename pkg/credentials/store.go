@@ -0,0 +1,254 @@
+package credentials
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv is an environment variable of the passphrase of the store.
+const PassphraseEnv = "HATENACTL_PASSPHRASE"
+
+const (
+	storeVersion = 1
+	kdfIteration = 100000
+	// maxKDFIteration is an upper bound of the iteration in the file, not to
+	// hang on a crafted file
+	maxKDFIteration = 10000000
+	keyLength       = 32
+	saltLength      = 16
+)
+
+// ErrAccountNotFound is returned when the account is not in the store.
+var ErrAccountNotFound = errors.New("account not found")
+
+// An Account represents credentials of a Hatena account.
+type Account struct {
+	// Auth is an authorization mode of the account (wsse | oauth1)
+	Auth string `json:"auth"`
+
+	OAuthConsumerKey    string `json:"oauth_consumer_key,omitempty"`
+	OAuthConsumerSecret string `json:"oauth_consumer_secret,omitempty"`
+	OAuthToken          string `json:"oauth_token,omitempty"`
+	OAuthTokenSecret    string `json:"oauth_token_secret,omitempty"`
+	// OAuthScopes is scopes requested on the authorization of the token,
+	// such as "read_public"
+	OAuthScopes []string `json:"oauth_scopes,omitempty"`
+
+	WSSEUsername string `json:"wsse_username,omitempty"`
+	WSSEPassword string `json:"wsse_password,omitempty"`
+}
+
+// A Store is a file of named accounts encrypted by the passphrase.  The file
+// is encrypted by AES-256-GCM with a key derived from the passphrase by
+// PBKDF2-HMAC-SHA256.
+type Store struct {
+	// Path is a path to the file
+	Path string
+	// Passphrase is a passphrase to encrypt the file
+	Passphrase string
+}
+
+// storeFile is a content of the file
+type storeFile struct {
+	Version    int    `json:"version"`
+	Iteration  int    `json:"iteration"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// DefaultPath returns the default path of the store in the user's config
+// directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hatenactl", "credentials"), nil
+}
+
+// Get returns the account of the name.  It returns ErrAccountNotFound if no
+// such account is stored.
+func (s *Store) Get(name string) (Account, error) {
+	accounts, err := s.Load()
+	if err != nil {
+		return Account{}, err
+	}
+	account, ok := accounts[name]
+	if !ok {
+		return Account{}, fmt.Errorf("%w: %q", ErrAccountNotFound, name)
+	}
+	return account, nil
+}
+
+// Put stores the account of the name.  The account is overwritten if it
+// already exists.
+func (s *Store) Put(name string, account Account) error {
+	accounts, err := s.Load()
+	if err != nil {
+		return err
+	}
+	accounts[name] = account
+	return s.Save(accounts)
+}
+
+// Load decrypts the file and returns all accounts.  It returns an empty map
+// if the file does not exist.
+func (s *Store) Load() (map[string]Account, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return make(map[string]Account), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var f storeFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("broken credentials file %s: %w", s.Path, err)
+	}
+	if f.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported credentials file version %d", f.Version)
+	}
+
+	aead, err := newAEAD(s.Passphrase, f.Salt, f.Iteration)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt credentials: wrong passphrase or broken file")
+	}
+
+	accounts := make(map[string]Account)
+	err = json.Unmarshal(plaintext, &accounts)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Save encrypts and writes the accounts into the file.
+func (s *Store) Save(accounts map[string]Account) error {
+	if len(s.Passphrase) == 0 {
+		return errors.New("empty passphrase")
+	}
+	plaintext, err := json.Marshal(accounts)
+	if err != nil {
+		return err
+	}
+
+	f := storeFile{
+		Version:   storeVersion,
+		Iteration: kdfIteration,
+		Salt:      make([]byte, saltLength),
+	}
+	_, err = rand.Read(f.Salt)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(s.Passphrase, f.Salt, f.Iteration)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(f.Nonce)
+	if err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.Path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".credentials-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+func newAEAD(passphrase string, salt []byte, iter int) (cipher.AEAD, error) {
+	if iter <= 0 || iter > maxKDFIteration {
+		return nil, fmt.Errorf("invalid iteration %d", iter)
+	}
+	key := pbkdf2.Key([]byte(passphrase), salt, iter, keyLength, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ReadPassphrase returns the passphrase from the environment variable
+// HATENACTL_PASSPHRASE.  It prompts the user to enter the passphrase on w, and
+// reads it from r, if the variable is not set.  The passphrase is read without
+// echo if r is a terminal.  Pass a *bufio.Reader shared with other reads if r
+// is not a terminal, so that the buffered input is not lost.
+func ReadPassphrase(w io.Writer, r io.Reader) (string, error) {
+	if v := os.Getenv(PassphraseEnv); len(v) > 0 {
+		return v, nil
+	}
+
+	fmt.Fprint(w, "Enter passphrase of the credentials: ")
+	var line string
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(w)
+		if err != nil {
+			return "", fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		line = string(b)
+	} else {
+		br, ok := r.(*bufio.Reader)
+		if !ok {
+			br = bufio.NewReader(r)
+		}
+		var err error
+		line, err = br.ReadString('\n')
+		if err != nil && !(err == io.EOF && len(line) > 0) {
+			return "", fmt.Errorf("unable to read passphrase: %w", err)
+		}
+	}
+	passphrase := strings.TrimRight(line, "\r\n")
+	if len(passphrase) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,112 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStorePutAndGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Store{Path: filepath.Join(dir, "hatenactl", "credentials"), Passphrase: "open sesame"}
+	err = s.Put("work", Account{Auth: "wsse", WSSEUsername: "alice", WSSEPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Put("home", Account{Auth: "oauth1", OAuthToken: "token", OAuthTokenSecret: "token-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := s.Get("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.WSSEUsername != "alice" || account.WSSEPassword != "secret" {
+		t.Errorf("unexpected account: %+v", account)
+	}
+	account, err = s.Get("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.OAuthToken != "token" {
+		t.Errorf("unexpected account: %+v", account)
+	}
+
+	_, err = s.Get("unknown")
+	if !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	fi, err := os.Stat(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("%o != %o", fi.Mode().Perm(), 0600)
+	}
+}
+
+func testStoreWrongPassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	s := &Store{Path: path, Passphrase: "open sesame"}
+	err = s.Put("work", Account{Auth: "wsse", WSSEPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s = &Store{Path: path, Passphrase: "close sesame"}
+	_, err = s.Get("work")
+	if err == nil {
+		t.Error("expected error on wrong passphrase")
+	}
+}
+
+func testStoreTooManyIterations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := json.Marshal(storeFile{
+		Version:   storeVersion,
+		Iteration: 1 << 40,
+		Salt:      make([]byte, saltLength),
+		Nonce:     make([]byte, 12),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Store{Path: path, Passphrase: "open sesame"}
+	_, err = s.Load()
+	if err == nil {
+		t.Error("expected error on too many iterations")
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Run("PutAndGet", testStorePutAndGet)
+	t.Run("WrongPassphrase", testStoreWrongPassphrase)
+	t.Run("TooManyIterations", testStoreTooManyIterations)
+}
@@ -0,0 +1,67 @@
+package apierror
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// An Error represents an error response from the server.  Callers can find
+// it by errors.As to decide whether to retry, re-authenticate or skip the
+// request.
+type Error struct {
+	// StatusCode is a status code of the response such as 404
+	StatusCode int
+	// Status is a status of the response such as "404 Not Found"
+	Status string
+	// Header is a header of the response
+	Header http.Header
+	// Body is a response body
+	Body []byte
+	// URL is a requested URL
+	URL string
+
+	// Retryable is true if the request may success by retrying later
+	Retryable bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server returns %d (%s): %s", e.StatusCode, e.Status, e.Body)
+}
+
+// FromResponse reads the response body and returns an Error from the
+// response.  The caller should close the response body.
+func FromResponse(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var u string
+	if resp.Request != nil && resp.Request.URL != nil {
+		u = resp.Request.URL.String()
+	}
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+		URL:        u,
+		Retryable:  IsRetryableStatus(resp.StatusCode),
+	}
+}
+
+// IsRetryableStatus returns true if the request is worth retrying on the
+// status code.
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromResponse(t *testing.T) {
+	cases := []struct {
+		code      int
+		retryable bool
+	}{
+		{code: http.StatusUnauthorized, retryable: false},
+		{code: http.StatusNotFound, retryable: false},
+		{code: http.StatusTooManyRequests, retryable: true},
+		{code: http.StatusServiceUnavailable, retryable: true},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "10")
+			http.Error(w, "oops", c.code)
+		}))
+
+		resp, err := server.Client().Get(server.URL + "/path")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = fmt.Errorf("wrapped: %w", FromResponse(resp))
+		resp.Body.Close()
+		server.Close()
+
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if apiErr.StatusCode != c.code {
+			t.Errorf("%d != %d", apiErr.StatusCode, c.code)
+		}
+		if apiErr.Retryable != c.retryable {
+			t.Errorf("%v != %v on %d", apiErr.Retryable, c.retryable, c.code)
+		}
+		if apiErr.URL != server.URL+"/path" {
+			t.Errorf("%q != %q", apiErr.URL, server.URL+"/path")
+		}
+		if apiErr.Header.Get("Retry-After") != "10" {
+			t.Errorf("%q != %q", apiErr.Header.Get("Retry-After"), "10")
+		}
+		if string(apiErr.Body) != "oops\n" {
+			t.Errorf("%q != %q", apiErr.Body, "oops\n")
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package auth provides authentication methods shared by commands.  Each
+// method is a Provider registered by the name, and it builds an
+// *http.Client from the configuration in the environment variables, flags or
+// a stored account.
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMode is the default name of the provider.
+const DefaultMode = "oauth1"
+
+// A Source looks up a value of the configuration by the key, such as
+// "OAUTH_TOKEN".  It returns empty string if the value is not presented.
+type Source func(key string) string
+
+// Env is a Source of the environment variables.
+func Env() Source {
+	return os.Getenv
+}
+
+// Values returns a Source of the map, such as values from flags.
+func Values(m map[string]string) Source {
+	return func(key string) string {
+		return m[key]
+	}
+}
+
+// Chain returns a Source looking up the sources in order.  The first
+// non-empty value is used.
+func Chain(sources ...Source) Source {
+	return func(key string) string {
+		for _, src := range sources {
+			if src == nil {
+				continue
+			}
+			if v := src(key); len(v) > 0 {
+				return v
+			}
+		}
+		return ""
+	}
+}
+
+// A Provider is an authentication method.
+type Provider interface {
+	// Name returns the name of the provider such as "wsse"
+	Name() string
+
+	// Load reads the configuration from the source
+	Load(src Source)
+
+	// Validate returns an error if the configuration is incomplete
+	Validate() error
+
+	// NewHTTPClient returns a new client authenticating requests
+	NewHTTPClient() *http.Client
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]func() Provider)
+)
+
+// Register makes the provider available by the name.  It panics if the name
+// is already registered.
+func Register(name string, factory func() Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := providers[name]; ok {
+		panic("auth: Register called twice for provider " + name)
+	}
+	providers[name] = factory
+}
+
+// Names returns the sorted names of the registered providers.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns a new provider of the name.
+func New(name string) (Provider, error) {
+	mu.RLock()
+	factory, ok := providers[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown authorization mode %q (%s)", name, strings.Join(Names(), " | "))
+	}
+	return factory(), nil
+}
+
+// Load returns a new provider of the name with the configuration loaded from
+// the source.  It returns an error if the configuration is incomplete.
+func Load(name string, src Source) (Provider, error) {
+	p, err := New(name)
+	if err != nil {
+		return nil, err
+	}
+	p.Load(src)
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ueokande/hatenactl/pkg/credentials"
+)
+
+func testLoad(t *testing.T) {
+	p, err := Load("wsse", Values(map[string]string{
+		"WSSE_USERNAME": "alice",
+		"WSSE_PASSWORD": "secret",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "wsse" {
+		t.Errorf("%q != %q", p.Name(), "wsse")
+	}
+	if p.NewHTTPClient() == nil {
+		t.Error("client is nil")
+	}
+
+	_, err = Load("oauth1", Values(map[string]string{
+		"OAUTH_CONSUMER_KEY":    "key",
+		"OAUTH_CONSUMER_SECRET": "secret",
+		"OAUTH_TOKEN":           "token",
+	}))
+	if err == nil || err.Error() != "OAUTH_TOKEN_SECRET not set" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = Load("basic", Values(nil))
+	if err == nil || err.Error() != `unknown authorization mode "basic" (oauth1 | wsse)` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testChain(t *testing.T) {
+	src := Chain(
+		Values(map[string]string{"WSSE_USERNAME": "alice"}),
+		nil,
+		Values(map[string]string{"WSSE_USERNAME": "bob", "WSSE_PASSWORD": "secret"}),
+	)
+	if v := src("WSSE_USERNAME"); v != "alice" {
+		t.Errorf("%q != %q", v, "alice")
+	}
+	if v := src("WSSE_PASSWORD"); v != "secret" {
+		t.Errorf("%q != %q", v, "secret")
+	}
+	if v := src("OAUTH_TOKEN"); v != "" {
+		t.Errorf("%q != %q", v, "")
+	}
+}
+
+func testOptionsProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hatenactl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	store := &credentials.Store{Path: path, Passphrase: "open sesame"}
+	err = store.Put("work", credentials.Account{Auth: "wsse", WSSEUsername: "alice", WSSEPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("WSSE_USERNAME", "bob")
+	defer os.Unsetenv("WSSE_USERNAME")
+
+	opts := Options{
+		Account:         "work",
+		CredentialsPath: path,
+		Passphrase:      func() (string, error) { return "open sesame", nil },
+	}
+	p, err := opts.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, ok := p.(*WSSE)
+	if !ok {
+		t.Fatalf("unexpected provider: %T", p)
+	}
+	if w.Username != "bob" {
+		t.Errorf("%q != %q", w.Username, "bob")
+	}
+	if w.Password != "secret" {
+		t.Errorf("%q != %q", w.Password, "secret")
+	}
+
+	opts.Mode = "oauth1"
+	_, err = opts.Provider()
+	if err == nil || err.Error() != "OAUTH_CONSUMER_KEY not set" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testCheckScopes(t *testing.T) {
+	p := &OAuth1{Scopes: []string{ScopeReadPublic, ScopeWritePublic}}
+	err := CheckScopes(p, ScopeReadPublic)
+	if err != nil {
+		t.Error(err)
+	}
+	err = CheckScopes(p, ScopeReadPublic, ScopeReadPrivate, ScopeWritePrivate)
+	if err == nil || err.Error() != "the token lacks scope read_private, write_private" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	p.Load(Values(map[string]string{"OAUTH_SCOPES": "read_public, write_private"}))
+	err = CheckScopes(p, ScopeWritePrivate)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = CheckScopes(p, ParseScopes("read_public, write_private,")...)
+	if err != nil {
+		t.Error(err)
+	}
+
+	p.Load(Values(nil))
+	err = CheckScopes(p, ScopeWritePrivate)
+	if err != nil {
+		t.Errorf("unknown scopes should not be checked: %v", err)
+	}
+	err = CheckScopes(&WSSE{}, ScopeWritePrivate)
+	if err != nil {
+		t.Errorf("wsse should not be checked: %v", err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Run("Load", testLoad)
+	t.Run("Chain", testChain)
+	t.Run("OptionsProvider", testOptionsProvider)
+	t.Run("CheckScopes", testCheckScopes)
+}
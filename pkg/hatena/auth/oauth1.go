@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
+)
+
+func init() {
+	Register("oauth1", func() Provider { return &OAuth1{} })
+}
+
+// OAuth1 is a Provider of the OAuth 1.0a authentication with HMAC-SHA1.  It
+// reads OAUTH_CONSUMER_KEY, OAUTH_CONSUMER_SECRET, OAUTH_TOKEN and
+// OAUTH_TOKEN_SECRET, and comma-separated scopes of the token in
+// OAUTH_SCOPES.
+type OAuth1 struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	Token          string
+	TokenSecret    string
+
+	// Scopes is scopes requested on the authorization of the token.  It is
+	// nil if unknown.
+	Scopes []string
+}
+
+func (p *OAuth1) Name() string {
+	return "oauth1"
+}
+
+func (p *OAuth1) Load(src Source) {
+	p.ConsumerKey = src("OAUTH_CONSUMER_KEY")
+	p.ConsumerSecret = src("OAUTH_CONSUMER_SECRET")
+	p.Token = src("OAUTH_TOKEN")
+	p.TokenSecret = src("OAUTH_TOKEN_SECRET")
+	p.Scopes = ParseScopes(src("OAUTH_SCOPES"))
+}
+
+func (p *OAuth1) Validate() error {
+	if len(p.ConsumerKey) == 0 {
+		return errors.New("OAUTH_CONSUMER_KEY not set")
+	}
+	if len(p.ConsumerSecret) == 0 {
+		return errors.New("OAUTH_CONSUMER_SECRET not set")
+	}
+	if len(p.Token) == 0 {
+		return errors.New("OAUTH_TOKEN not set")
+	}
+	if len(p.TokenSecret) == 0 {
+		return errors.New("OAUTH_TOKEN_SECRET not set")
+	}
+	return nil
+}
+
+func (p *OAuth1) NewHTTPClient() *http.Client {
+	return oauth1.NewHTTPClient(p.ConsumerKey, p.ConsumerSecret, p.Token, p.TokenSecret)
+}
+
+func (p *OAuth1) GrantedScopes() []string {
+	return p.Scopes
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ueokande/hatenactl/pkg/credentials"
+)
+
+// Options is a configuration of the authentication given to commands.
+type Options struct {
+	// Mode is a name of the provider.  The mode of the account, or
+	// DefaultMode is used if empty.
+	Mode string
+	// Account is a name of the account in the credentials store.  The
+	// credentials store is not used if empty.
+	Account string
+	// CredentialsPath is a path to the credentials store.
+	// credentials.DefaultPath() is used if empty.
+	CredentialsPath string
+
+	// Passphrase returns the passphrase of the credentials store.  It reads
+	// the passphrase by credentials.ReadPassphrase() if nil.
+	Passphrase func() (string, error)
+}
+
+// Provider returns the provider loaded from the environment variables and
+// the account in the credentials store.  The environment variables override
+// the stored values.
+func (o Options) Provider() (Provider, error) {
+	mode := o.Mode
+	src := Env()
+	if len(o.Account) > 0 {
+		account, err := o.loadAccount()
+		if err != nil {
+			return nil, err
+		}
+		if len(mode) == 0 {
+			mode = account.Auth
+		}
+		src = Chain(Env(), FromAccount(account))
+	}
+	if len(mode) == 0 {
+		mode = DefaultMode
+	}
+	return Load(mode, src)
+}
+
+func (o Options) loadAccount() (credentials.Account, error) {
+	path := o.CredentialsPath
+	if len(path) == 0 {
+		var err error
+		path, err = credentials.DefaultPath()
+		if err != nil {
+			return credentials.Account{}, err
+		}
+	}
+	readPassphrase := o.Passphrase
+	if readPassphrase == nil {
+		readPassphrase = func() (string, error) {
+			return credentials.ReadPassphrase(os.Stderr, os.Stdin)
+		}
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return credentials.Account{}, err
+	}
+
+	store := &credentials.Store{Path: path, Passphrase: passphrase}
+	return store.Get(o.Account)
+}
+
+// FromAccount returns a Source of the stored account.
+func FromAccount(account credentials.Account) Source {
+	return Values(map[string]string{
+		"OAUTH_CONSUMER_KEY":    account.OAuthConsumerKey,
+		"OAUTH_CONSUMER_SECRET": account.OAuthConsumerSecret,
+		"OAUTH_TOKEN":           account.OAuthToken,
+		"OAUTH_TOKEN_SECRET":    account.OAuthTokenSecret,
+		"OAUTH_SCOPES":          strings.Join(account.OAuthScopes, ","),
+		"WSSE_USERNAME":         account.WSSEUsername,
+		"WSSE_PASSWORD":         account.WSSEPassword,
+	})
+}
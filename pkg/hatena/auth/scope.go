@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"strings"
+)
+
+// Scopes of the OAuth token of Hatena.
+const (
+	ScopeReadPublic   = "read_public"
+	ScopeWritePublic  = "write_public"
+	ScopeReadPrivate  = "read_private"
+	ScopeWritePrivate = "write_private"
+)
+
+// A Scoper is a Provider whose credentials are limited by scopes.
+type Scoper interface {
+	// GrantedScopes returns scopes of the credentials, or nil if unknown.
+	// The scopes are ones requested on the authorization, since the
+	// server does not tell the scopes of the token.
+	GrantedScopes() []string
+}
+
+// ParseScopes returns the scopes in the comma-separated list, such as
+// "read_public, write_public".  Spaces around the scopes and empty items are
+// dropped.  It returns nil if no scopes are in the list.
+func ParseScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); len(scope) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// ScopeError is returned when the credentials lack required scopes.
+type ScopeError struct {
+	Missing []string
+}
+
+func (e *ScopeError) Error() string {
+	return "the token lacks scope " + strings.Join(e.Missing, ", ")
+}
+
+// CheckScopes returns a *ScopeError if the provider lacks any of the required
+// scopes.  It returns nil if the provider is not limited by scopes, or the
+// granted scopes are unknown.
+func CheckScopes(p Provider, required ...string) error {
+	s, ok := p.(Scoper)
+	if !ok {
+		return nil
+	}
+	granted := s.GrantedScopes()
+	if granted == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return &ScopeError{Missing: missing}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/wsse"
+)
+
+func init() {
+	Register("wsse", func() Provider { return &WSSE{} })
+}
+
+// WSSE is a Provider of the WSSE authentication.  It reads WSSE_USERNAME
+// and WSSE_PASSWORD.
+type WSSE struct {
+	Username string
+	Password string
+}
+
+func (p *WSSE) Name() string {
+	return "wsse"
+}
+
+func (p *WSSE) Load(src Source) {
+	p.Username = src("WSSE_USERNAME")
+	p.Password = src("WSSE_PASSWORD")
+}
+
+func (p *WSSE) Validate() error {
+	if len(p.Username) == 0 {
+		return errors.New("WSSE_USERNAME not set")
+	}
+	if len(p.Password) == 0 {
+		return errors.New("WSSE_PASSWORD not set")
+	}
+	return nil
+}
+
+func (p *WSSE) NewHTTPClient() *http.Client {
+	return wsse.NewHTTPClient(p.Username, p.Password)
+}
@@ -1,13 +1,17 @@
 package blog
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
 )
 
 // A Client is a client for HatenaBlog using Atom API.
@@ -15,8 +19,15 @@ import (
 // http://developer.hatena.ne.jp/ja/documents/blog/apis/atom
 type Client struct {
 	HTTPClient *http.Client
+
+	// BaseURL is a base URL of the API endpoint.  DefaultBaseURL is used if
+	// empty.
+	BaseURL string
 }
 
+// DefaultBaseURL is a base URL of the Atom API of the HatenaBlog.
+const DefaultBaseURL = "https://blog.hatena.ne.jp"
+
 // ListEntriesInput represents an input parameter of the Client.ListEntries
 type ListEntriesInput struct {
 	// HatenaID (blog owner)
@@ -29,10 +40,9 @@ type ListEntriesInput struct {
 }
 
 func (c *Client) ListEntries(ctx context.Context, input ListEntriesInput) (*Feed, error) {
-	u := &url.URL{
-		Scheme: "https",
-		Host:   "blog.hatena.ne.jp",
-		Path:   path.Join(input.HatenaID, input.BlogID, "atom", "entry"),
+	u, err := c.collectionURL(input.HatenaID, input.BlogID)
+	if err != nil {
+		return nil, err
 	}
 	if len(input.Page) > 0 {
 		q := u.Query()
@@ -44,24 +54,256 @@ func (c *Client) ListEntries(ctx context.Context, input ListEntriesInput) (*Feed
 		return nil, err
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	var feed Feed
+	err = c.do(req, &feed)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetEntryInput represents an input parameter of the Client.GetEntry
+type GetEntryInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+	// EntryID to be fetched
+	EntryID string
+}
+
+// GetEntry fetches the entry on the member URI.  The member URI of the entry
+// is also presented by Entry.EditURI() of the returned entry.
+func (c *Client) GetEntry(ctx context.Context, input GetEntryInput) (*Entry, error) {
+	u, err := c.memberURL(input.HatenaID, input.BlogID, input.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	err = c.do(req, &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreateEntryInput represents an input parameter of the Client.CreateEntry
+type CreateEntryInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+
+	// Entry to be posted.  The title, content, categories, updated time and
+	// draft flag are sent to the server.
+	Entry Entry
+}
+
+// CreateEntry posts a new entry to the collection URI, and returns the entry
+// created by the server.
+func (c *Client) CreateEntry(ctx context.Context, input CreateEntryInput) (*Entry, error) {
+	u, err := c.collectionURL(input.HatenaID, input.BlogID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newEntryRequest(ctx, http.MethodPost, u.String(), input.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	err = c.do(req, &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// UpdateEntryInput represents an input parameter of the Client.UpdateEntry
+type UpdateEntryInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+	// EntryID to be updated
+	EntryID string
+
+	// Entry to replace with.  The title, content, categories, updated time
+	// and draft flag are sent to the server.
+	Entry Entry
+}
+
+// UpdateEntry replaces the entry on the member URI, and returns the entry
+// updated by the server.
+func (c *Client) UpdateEntry(ctx context.Context, input UpdateEntryInput) (*Entry, error) {
+	u, err := c.memberURL(input.HatenaID, input.BlogID, input.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newEntryRequest(ctx, http.MethodPut, u.String(), input.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	err = c.do(req, &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteEntryInput represents an input parameter of the Client.DeleteEntry
+type DeleteEntryInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+	// EntryID to be deleted
+	EntryID string
+}
+
+// DeleteEntry deletes the entry on the member URI.
+func (c *Client) DeleteEntry(ctx context.Context, input DeleteEntryInput) error {
+	u, err := c.memberURL(input.HatenaID, input.BlogID, input.EntryID)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
+// GetServiceInput represents an input parameter of the Client.GetService
+type GetServiceInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+}
+
+// GetService fetches the service document of the blog.
+func (c *Client) GetService(ctx context.Context, input GetServiceInput) (*Service, error) {
+	u, err := c.blogURL(input.HatenaID, input.BlogID)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "atom")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var service Service
+	err = c.do(req, &service)
+	if err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// GetCategoriesInput represents an input parameter of the Client.GetCategories
+type GetCategoriesInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+}
+
+// GetCategories fetches the category document of the blog.
+func (c *Client) GetCategories(ctx context.Context, input GetCategoriesInput) (*Categories, error) {
+	u, err := c.blogURL(input.HatenaID, input.BlogID)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "atom", "category")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories Categories
+	err = c.do(req, &categories)
+	if err != nil {
+		return nil, err
+	}
+	return &categories, nil
+}
+
+// blogURL returns a root URL of the Atom API of the blog.
+func (c *Client) blogURL(hatenaID, blogID string) (*url.URL, error) {
+	base := c.BaseURL
+	if len(base) == 0 {
+		base = DefaultBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %q: %w", base, err)
+	}
+	u.Path = path.Join("/", u.Path, hatenaID, blogID)
+	return u, nil
+}
+
+// collectionURL returns a collection URI of the entries in the blog.
+func (c *Client) collectionURL(hatenaID, blogID string) (*url.URL, error) {
+	u, err := c.blogURL(hatenaID, blogID)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "atom", "entry")
+	return u, nil
+}
+
+// memberURL returns a member URI of the entry in the blog.
+func (c *Client) memberURL(hatenaID, blogID, entryID string) (*url.URL, error) {
+	u, err := c.collectionURL(hatenaID, blogID)
 	if err != nil {
 		return nil, err
 	}
+	u.Path = path.Join(u.Path, entryID)
+	return u, nil
+}
+
+// do sends the request and decodes the response body into v as XML.  The
+// response body is discarded if v is nil.
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		respBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("server returns %d (%s): %s", resp.StatusCode, resp.Status, respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return apierror.FromResponse(resp)
 	}
 
-	var feed Feed
-	err = xml.NewDecoder(resp.Body).Decode(&feed)
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// newEntryRequest returns a new request with the entry encoded as the Atom
+// XML in the request body.
+func newEntryRequest(ctx context.Context, method, url string, entry Entry) (*http.Request, error) {
+	body, err := xml.Marshal(newAtomEntry(entry))
 	if err != nil {
 		return nil, err
 	}
-	return &feed, nil
+	body = append([]byte(xml.Header), body...)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/atom+xml; type=entry")
+	return req, nil
 }
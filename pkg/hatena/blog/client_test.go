@@ -0,0 +1,213 @@
+package blog
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+const testEntryResponse = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom" xmlns:app="http://www.w3.org/2007/app">
+  <id>tag:blog.hatena.ne.jp,2013:blog-ueokande-12704346814673868829-26006613527446307</id>
+  <link rel="edit" href="https://blog.hatena.ne.jp/ueokande/ueokande.hatenablog.com/atom/entry/26006613527446307"/>
+  <title>Greeting</title>
+  <app:control><app:draft>yes</app:draft></app:control>
+</entry>`
+
+func testClientCreateEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("%q != %q", r.Method, http.MethodPost)
+		}
+		if r.URL.Path != "/ueokande/ueokande.hatenablog.com/atom/entry" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var entry Entry
+		err = xml.Unmarshal(body, &entry)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if entry.Title != "Greeting" || entry.Content.Content != "Hello, world" {
+			t.Errorf("unexpected entry: %s", body)
+		}
+		if entry.Control.Draft != "yes" {
+			t.Errorf("%q != %q", entry.Control.Draft, "yes")
+		}
+		if len(entry.Categories) != 1 || entry.Categories[0].Term != "Greetings" {
+			t.Errorf("unexpected categories: %s", body)
+		}
+		if !strings.Contains(string(body), `<app:control><app:draft>yes</app:draft></app:control>`) {
+			t.Errorf("no draft control: %s", body)
+		}
+		if v := r.Header.Get("Content-Type"); v != "application/atom+xml; type=entry" {
+			t.Errorf("%q != %q", v, "application/atom+xml; type=entry")
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(testEntryResponse))
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	entry, err := c.CreateEntry(context.Background(), CreateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		Entry: Entry{
+			Title:      "Greeting",
+			Content:    Content{Type: "text/x-markdown", Content: "Hello, world"},
+			Categories: []Category{{Term: "Greetings"}},
+			Control:    Control{Draft: "yes"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(entry.EditURI(), "/atom/entry/26006613527446307") {
+		t.Errorf("unexpected edit uri: %q", entry.EditURI())
+	}
+}
+
+func testClientGetEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ueokande/ueokande.hatenablog.com/atom/entry/26006613527446307" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		w.Write([]byte(testEntryResponse))
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	entry, err := c.GetEntry(context.Background(), GetEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  "26006613527446307",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := entry.EntryID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Entry != "26006613527446307" {
+		t.Errorf("%q != %q", id.Entry, "26006613527446307")
+	}
+}
+
+func testClientUpdateEntry(t *testing.T) {
+	updated := time.Date(2020, 3, 1, 12, 34, 56, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("%q != %q", r.Method, http.MethodPut)
+		}
+		if r.URL.Path != "/ueokande/ueokande.hatenablog.com/atom/entry/26006613527446307" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var entry Entry
+		err = xml.Unmarshal(body, &entry)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if entry.Title != "Greeting again" || entry.Content.Content != "Hello again" {
+			t.Errorf("unexpected entry: %s", body)
+		}
+		if !entry.Updated.Equal(updated) {
+			t.Errorf("%v != %v", entry.Updated, updated)
+		}
+		// The entry is published unless the draft is specified
+		if !strings.Contains(string(body), `<app:control><app:draft>no</app:draft></app:control>`) {
+			t.Errorf("no draft control: %s", body)
+		}
+
+		w.Write([]byte(testEntryResponse))
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	entry, err := c.UpdateEntry(context.Background(), UpdateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  "26006613527446307",
+		Entry: Entry{
+			Title:   "Greeting again",
+			Content: Content{Type: "text/x-markdown", Content: "Hello again"},
+			Updated: updated,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Title != "Greeting" {
+		t.Errorf("%q != %q", entry.Title, "Greeting")
+	}
+}
+
+func testClientDeleteEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("%q != %q", r.Method, http.MethodDelete)
+		}
+		if r.URL.Path != "/ueokande/ueokande.hatenablog.com/atom/entry/26006613527446307" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	err := c.DeleteEntry(context.Background(), DeleteEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  "26006613527446307",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testClientErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	_, err := c.ListEntries(context.Background(), ListEntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("%d != %d", apiErr.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("CreateEntry", testClientCreateEntry)
+	t.Run("GetEntry", testClientGetEntry)
+	t.Run("UpdateEntry", testClientUpdateEntry)
+	t.Run("DeleteEntry", testClientDeleteEntry)
+	t.Run("ErrorResponse", testClientErrorResponse)
+}
@@ -73,6 +73,17 @@ func (e Entry) OriginalLink() *Link {
 	return nil
 }
 
+// EditURI returns the member URI of the entry to fetch, update or delete the
+// entry.  It returns empty string if the edit link is not presented.
+func (e Entry) EditURI() string {
+	for _, l := range e.Links {
+		if l.Rel == "edit" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
 func (e Entry) Path() string {
 	link := e.OriginalLink()
 	if link == nil {
@@ -114,3 +125,55 @@ type Category struct {
 type Control struct {
 	Draft string `xml:"draft"`
 }
+
+const (
+	atomNamespace = "http://www.w3.org/2005/Atom"
+	appNamespace  = "http://www.w3.org/2007/app"
+)
+
+// atomEntry represents an entry sent to the server on posting or updating
+// the entry.
+type atomEntry struct {
+	XMLName xml.Name `xml:"entry"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	App     string   `xml:"xmlns:app,attr"`
+
+	Title      string      `xml:"title"`
+	Author     *Author     `xml:"author,omitempty"`
+	Content    atomContent `xml:"content"`
+	Updated    *time.Time  `xml:"updated,omitempty"`
+	Categories []Category  `xml:"category"`
+	Control    atomControl `xml:"app:control"`
+}
+
+type atomContent struct {
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+type atomControl struct {
+	Draft string `xml:"app:draft"`
+}
+
+// newAtomEntry converts the entry into the form to be sent to the server.
+func newAtomEntry(e Entry) atomEntry {
+	a := atomEntry{
+		Xmlns:      atomNamespace,
+		App:        appNamespace,
+		Title:      e.Title,
+		Content:    atomContent{Type: e.Content.Type, Content: e.Content.Content},
+		Categories: e.Categories,
+		Control:    atomControl{Draft: "no"},
+	}
+	if len(e.Author.Name) > 0 {
+		a.Author = &Author{Name: e.Author.Name}
+	}
+	if !e.Updated.IsZero() {
+		updated := e.Updated
+		a.Updated = &updated
+	}
+	if len(e.Control.Draft) > 0 {
+		a.Control.Draft = e.Control.Draft
+	}
+	return a
+}
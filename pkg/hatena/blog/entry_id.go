@@ -0,0 +1,63 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const entryIDPrefix = "tag:blog.hatena.ne.jp,2013:blog-"
+
+// An EntryID represents an identifier of the entry presented in Entry.ID.  It
+// is formatted as the following:
+//
+//    tag:blog.hatena.ne.jp,2013:blog-{user}-{blog}-{entry}
+type EntryID struct {
+	// User is a hatena ID of the blog owner
+	User string
+	// Blog is a numeric ID of the blog
+	Blog string
+	// Entry is an ID of the entry used in the member URI
+	Entry string
+}
+
+// ParseEntryID parses s as an entry ID.
+func ParseEntryID(s string) (EntryID, error) {
+	if !strings.HasPrefix(s, entryIDPrefix) {
+		return EntryID{}, fmt.Errorf("invalid entry id %q", s)
+	}
+	body := strings.TrimPrefix(s, entryIDPrefix)
+
+	// The hatena ID may contain "-", but the blog and the entry may not
+	i := strings.LastIndex(body, "-")
+	if i < 0 {
+		return EntryID{}, fmt.Errorf("invalid entry id %q", s)
+	}
+	j := strings.LastIndex(body[:i], "-")
+	if j < 0 {
+		return EntryID{}, fmt.Errorf("invalid entry id %q", s)
+	}
+
+	id := EntryID{
+		User:  body[:j],
+		Blog:  body[j+1 : i],
+		Entry: body[i+1:],
+	}
+	if len(id.User) == 0 || len(id.Blog) == 0 || len(id.Entry) == 0 {
+		return EntryID{}, fmt.Errorf("invalid entry id %q", s)
+	}
+	return id, nil
+}
+
+// String returns the entry ID in the tag form.
+func (id EntryID) String() string {
+	return entryIDPrefix + id.User + "-" + id.Blog + "-" + id.Entry
+}
+
+// EntryID returns the parsed ID of the entry.
+func (e Entry) EntryID() (EntryID, error) {
+	if len(e.ID) == 0 {
+		return EntryID{}, errors.New("entry has no id")
+	}
+	return ParseEntryID(e.ID)
+}
@@ -0,0 +1,53 @@
+package blog
+
+import "testing"
+
+func testParseEntryID(t *testing.T) {
+	cases := []struct {
+		src    string
+		result EntryID
+	}{
+		{
+			src:    "tag:blog.hatena.ne.jp,2013:blog-ueokande-12704346814673868829-26006613527446307",
+			result: EntryID{User: "ueokande", Blog: "12704346814673868829", Entry: "26006613527446307"},
+		},
+		{
+			src:    "tag:blog.hatena.ne.jp,2013:blog-my-name-12704346814673868829-26006613527446307",
+			result: EntryID{User: "my-name", Blog: "12704346814673868829", Entry: "26006613527446307"},
+		},
+	}
+	for _, c := range cases {
+		id, err := ParseEntryID(c.src)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if id != c.result {
+			t.Errorf("%+v != %+v", id, c.result)
+		}
+		if id.String() != c.src {
+			t.Errorf("%q != %q", id.String(), c.src)
+		}
+	}
+}
+
+func testParseEntryIDInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"tag:blog.hatena.ne.jp,2013:blog-",
+		"tag:blog.hatena.ne.jp,2013:blog-ueokande-26006613527446307",
+		"tag:blog.hatena.ne.jp,2013:blog-ueokande-12704346814673868829-",
+		"tag:example.com,2013:blog-ueokande-12704346814673868829-26006613527446307",
+	}
+	for _, c := range cases {
+		_, err := ParseEntryID(c)
+		if err == nil {
+			t.Errorf("expected error on %q", c)
+		}
+	}
+}
+
+func TestEntryID(t *testing.T) {
+	t.Run("Parse", testParseEntryID)
+	t.Run("ParseInvalid", testParseEntryIDInvalid)
+}
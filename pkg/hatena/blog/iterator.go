@@ -0,0 +1,131 @@
+package blog
+
+import (
+	"context"
+	"time"
+)
+
+// EntriesInput represents an input parameter of the Client.Entries
+type EntriesInput struct {
+	// HatenaID (blog owner)
+	HatenaID string
+	// BlobID
+	BlogID string
+
+	// Page is a page token to start listing from.  The iterator starts from
+	// the first page if empty.
+	Page string
+	// Delay is a duration to wait before fetching the next page
+	Delay time.Duration
+	// Limit is the maximum number of the entries to iterate.  There is no
+	// limit if zero.
+	Limit int
+}
+
+// An EntryIterator is an iterator of the entries in the blog across the
+// pages.
+//
+//    it := client.Entries(ctx, input)
+//    for it.Next() {
+//        entry := it.Entry()
+//        ...
+//    }
+//    if err := it.Err(); err != nil {
+//        ...
+//    }
+type EntryIterator struct {
+	ctx    context.Context
+	client *Client
+	input  EntriesInput
+
+	entries []Entry
+	index   int
+	count   int
+	fetched bool
+	page    string
+	next    string
+	err     error
+}
+
+// Entries returns an iterator of the entries in the blog.  The iterator
+// fetches the pages lazily on Next().
+func (c *Client) Entries(ctx context.Context, input EntriesInput) *EntryIterator {
+	return &EntryIterator{
+		ctx:    ctx,
+		client: c,
+		input:  input,
+		index:  -1,
+		next:   input.Page,
+	}
+}
+
+// Next advances the iterator to the next entry.  It returns false when the
+// iteration is finished or an error occurs.
+func (it *EntryIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.input.Limit > 0 && it.count >= it.input.Limit {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	it.index++
+	for it.index >= len(it.entries) {
+		if it.fetched && len(it.next) == 0 {
+			return false
+		}
+		if it.fetched && it.input.Delay > 0 {
+			t := time.NewTimer(it.input.Delay)
+			select {
+			case <-it.ctx.Done():
+				t.Stop()
+				it.err = it.ctx.Err()
+				return false
+			case <-t.C:
+			}
+		}
+
+		feed, err := it.client.ListEntries(it.ctx, ListEntriesInput{
+			HatenaID: it.input.HatenaID,
+			BlogID:   it.input.BlogID,
+			Page:     it.next,
+		})
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.page = it.next
+		it.next = feed.NextPage()
+		it.entries = feed.Entries
+		it.index = 0
+	}
+	it.count++
+	return true
+}
+
+// Entry returns the current entry.
+func (it *EntryIterator) Entry() Entry {
+	return it.entries[it.index]
+}
+
+// Page returns a page token of the page containing the current entry.  It is
+// empty on the first page.
+func (it *EntryIterator) Page() string {
+	return it.page
+}
+
+// NextPage returns a page token of the page following the current page.  It
+// is empty on the last page.
+func (it *EntryIterator) NextPage() string {
+	return it.next
+}
+
+// Err returns an error occurred on the iteration.
+func (it *EntryIterator) Err() error {
+	return it.err
+}
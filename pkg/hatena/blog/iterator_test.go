@@ -0,0 +1,131 @@
+package blog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newPagedServer returns a server presenting the pages of the entries.  Each
+// page has two entries.
+func newPagedServer(t *testing.T, pages int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		if p := r.URL.Query().Get("page"); len(p) > 0 {
+			var err error
+			page, err = strconv.Atoi(p)
+			if err != nil {
+				t.Errorf("unexpected page: %q", p)
+			}
+		}
+
+		first := server.URL + "/ueokande/ueokande.hatenablog.com/atom/entry"
+		fmt.Fprintln(w, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+		fmt.Fprintf(w, `<link rel="first" href="%s"/>`, first)
+		if page+1 < pages {
+			fmt.Fprintf(w, `<link rel="next" href="%s?page=%d"/>`, first, page+1)
+		}
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, `<entry><title>entry-%d-%d</title></entry>`, page, i)
+		}
+		fmt.Fprintln(w, `</feed>`)
+	}))
+	return server
+}
+
+func testEntryIteratorAll(t *testing.T) {
+	server := newPagedServer(t, 3)
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	it := c.Entries(context.Background(), EntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+
+	var titles []string
+	var pages []string
+	for it.Next() {
+		titles = append(titles, it.Entry().Title)
+		pages = append(pages, it.Page())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"entry-0-0", "entry-0-1", "entry-1-0", "entry-1-1", "entry-2-0", "entry-2-1"}
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("%q != %q", titles, expected)
+	}
+	expectedPages := []string{"", "", "1", "1", "2", "2"}
+	if fmt.Sprint(pages) != fmt.Sprint(expectedPages) {
+		t.Errorf("%q != %q", pages, expectedPages)
+	}
+}
+
+func testEntryIteratorPageAndLimit(t *testing.T) {
+	server := newPagedServer(t, 3)
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	it := c.Entries(context.Background(), EntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		Page:     "1",
+		Limit:    3,
+	})
+
+	var titles []string
+	for it.Next() {
+		titles = append(titles, it.Entry().Title)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"entry-1-0", "entry-1-1", "entry-2-0"}
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("%q != %q", titles, expected)
+	}
+}
+
+func testEntryIteratorCancel(t *testing.T) {
+	server := newPagedServer(t, 3)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	it := c.Entries(ctx, EntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		Delay:    time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		if !it.Next() {
+			t.Fatal(it.Err())
+		}
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if it.Next() {
+		t.Fatal("iterator must stop on cancel")
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("unexpected error: %v", it.Err())
+	}
+}
+
+func TestEntryIterator(t *testing.T) {
+	t.Run("All", testEntryIteratorAll)
+	t.Run("PageAndLimit", testEntryIteratorPageAndLimit)
+	t.Run("Cancel", testEntryIteratorCancel)
+}
@@ -0,0 +1,55 @@
+package blog
+
+import (
+	"encoding/xml"
+)
+
+// A Service represents a service document of the blog.  It presents
+// collections in the blog.
+type Service struct {
+	XMLName xml.Name `xml:"service"`
+
+	Workspaces []Workspace `xml:"workspace"`
+}
+
+// A Workspace represents a workspace in the service document.
+type Workspace struct {
+	Title       string       `xml:"title"`
+	Collections []Collection `xml:"collection"`
+}
+
+// A Collection represents a collection of the workspace.  The Href is a
+// collection URI to list or post entries.
+type Collection struct {
+	Href    string   `xml:"href,attr"`
+	Title   string   `xml:"title"`
+	Accepts []string `xml:"accept"`
+}
+
+// A Categories represents a category document of the blog.
+type Categories struct {
+	XMLName xml.Name `xml:"categories"`
+
+	// Fixed is "yes" if the blog accepts only categories in the document
+	Fixed      string     `xml:"fixed,attr"`
+	Categories []Category `xml:"category"`
+}
+
+// Contains returns true if the term is in the category document.
+func (c Categories) Contains(term string) bool {
+	for _, cat := range c.Categories {
+		if cat.Term == term {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows returns true if the term is acceptable as a category of the entry.
+// All terms are acceptable unless the categories are fixed.
+func (c Categories) Allows(term string) bool {
+	if c.Fixed != "yes" {
+		return true
+	}
+	return c.Contains(term)
+}
@@ -0,0 +1,150 @@
+package blog
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+const testServiceDocument = `<?xml version="1.0" encoding="utf-8"?>
+<service xmlns="http://www.w3.org/2007/app" xmlns:atom="http://www.w3.org/2005/Atom">
+  <workspace>
+    <atom:title>My Blog</atom:title>
+    <collection href="https://blog.hatena.ne.jp/ueokande/ueokande.hatenablog.com/atom/entry">
+      <atom:title>My Blog - Entries</atom:title>
+      <accept>application/atom+xml;type=entry</accept>
+    </collection>
+  </workspace>
+</service>`
+
+const testCategoriesDocument = `<?xml version="1.0" encoding="utf-8"?>
+<app:categories xmlns:app="http://www.w3.org/2007/app" xmlns:atom="http://www.w3.org/2005/Atom" fixed="yes">
+  <atom:category term="Perl" />
+  <atom:category term="Scala" />
+</app:categories>`
+
+func testDecodeService(t *testing.T) {
+	var service Service
+	err := xml.Unmarshal([]byte(testServiceDocument), &service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(service.Workspaces) != 1 {
+		t.Fatalf("%d != 1", len(service.Workspaces))
+	}
+	ws := service.Workspaces[0]
+	if ws.Title != "My Blog" {
+		t.Errorf("%q != %q", ws.Title, "My Blog")
+	}
+	if len(ws.Collections) != 1 {
+		t.Fatalf("%d != 1", len(ws.Collections))
+	}
+	col := ws.Collections[0]
+	if col.Href != "https://blog.hatena.ne.jp/ueokande/ueokande.hatenablog.com/atom/entry" {
+		t.Errorf("unexpected href: %q", col.Href)
+	}
+	if len(col.Accepts) != 1 || col.Accepts[0] != "application/atom+xml;type=entry" {
+		t.Errorf("unexpected accepts: %q", col.Accepts)
+	}
+}
+
+func testDecodeCategories(t *testing.T) {
+	var cats Categories
+	err := xml.Unmarshal([]byte(testCategoriesDocument), &cats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cats.Categories) != 2 {
+		t.Fatalf("%d != 2", len(cats.Categories))
+	}
+	if !cats.Allows("Perl") || !cats.Allows("Scala") {
+		t.Errorf("categories in the document must be allowed")
+	}
+	if cats.Allows("Go") {
+		t.Errorf("unknown category must not be allowed")
+	}
+
+	cats.Fixed = "no"
+	if !cats.Allows("Go") {
+		t.Errorf("any category must be allowed if not fixed")
+	}
+}
+
+// newTestDocumentServer returns a server responding the document on GET
+// requests to the path, or 404 on other paths.
+func newTestDocumentServer(t *testing.T, path, doc string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("%q != %q", r.Method, http.MethodGet)
+		}
+		if r.URL.Path != path {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+}
+
+func testClientGetService(t *testing.T) {
+	server := newTestDocumentServer(t, "/ueokande/ueokande.hatenablog.com/atom", testServiceDocument)
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	service, err := c.GetService(context.Background(), GetServiceInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(service.Workspaces) != 1 || service.Workspaces[0].Title != "My Blog" {
+		t.Errorf("unexpected service: %+v", service)
+	}
+
+	_, err = c.GetService(context.Background(), GetServiceInput{
+		HatenaID: "ueokande",
+		BlogID:   "unknown.hatenablog.com",
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testClientGetCategories(t *testing.T) {
+	server := newTestDocumentServer(t, "/ueokande/ueokande.hatenablog.com/atom/category", testCategoriesDocument)
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	cats, err := c.GetCategories(context.Background(), GetCategoriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cats.Categories) != 2 || !cats.Allows("Perl") {
+		t.Errorf("unexpected categories: %+v", cats)
+	}
+
+	_, err = c.GetCategories(context.Background(), GetCategoriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "unknown.hatenablog.com",
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestService(t *testing.T) {
+	t.Run("DecodeService", testDecodeService)
+	t.Run("DecodeCategories", testDecodeCategories)
+	t.Run("GetService", testClientGetService)
+	t.Run("GetCategories", testClientGetCategories)
+}
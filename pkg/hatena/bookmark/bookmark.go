@@ -0,0 +1,40 @@
+package bookmark
+
+import (
+	"time"
+)
+
+// A Bookmark is a bookmark of the URL by the user.
+type Bookmark struct {
+	// User is a Hatena ID of the user
+	User string `json:"user"`
+	// Comment is a comment of the bookmark without tags
+	Comment string `json:"comment"`
+	// CommentRaw is a comment of the bookmark including tags such as
+	// "[golang]comment"
+	CommentRaw string    `json:"comment_raw"`
+	Tags       []string  `json:"tags"`
+	Private    bool      `json:"private"`
+	Permalink  string    `json:"permalink"`
+	CreatedAt  time.Time `json:"created_datetime"`
+}
+
+// An Entry is a bookmarked page on the Hatena Bookmark.
+type Entry struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// EntryURL is an URL of the entry page on the Hatena Bookmark
+	EntryURL string `json:"entry_url"`
+	// Count is the number of the users bookmarking the page
+	Count                 int    `json:"count"`
+	FaviconURL            string `json:"favicon_url"`
+	SmartphoneAppEntryURL string `json:"smartphone_app_entry_url"`
+}
+
+// A Tag is a tag used in the bookmarks of the user.
+type Tag struct {
+	Tag string `json:"tag"`
+	// Count is the number of the bookmarks with the tag
+	Count      int       `json:"count"`
+	ModifiedAt time.Time `json:"modified_datetime"`
+}
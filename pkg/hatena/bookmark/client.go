@@ -0,0 +1,188 @@
+// Package bookmark provides a client of the Hatena Bookmark REST API.
+package bookmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+// A Client is a client for Hatena Bookmark REST API.  The HTTPClient should
+// authenticate requests by oauth1.Transport.  The Atom feed of the bookmarks
+// also accepts wsse.Transport.
+//
+// http://developer.hatena.ne.jp/ja/documents/bookmark/apis/rest
+type Client struct {
+	HTTPClient *http.Client
+
+	// BaseURL is a base URL of the API endpoint.  DefaultBaseURL is used if
+	// empty.
+	BaseURL string
+	// FeedURL is an URL of the Atom feed of the bookmarks.  DefaultFeedURL
+	// is used if empty.
+	FeedURL string
+}
+
+// DefaultBaseURL is a base URL of the Hatena Bookmark REST API.
+const DefaultBaseURL = "https://bookmark.hatenaapis.com/rest/1"
+
+// SaveBookmarkInput represents an input parameter of the
+// Client.SaveBookmark
+type SaveBookmarkInput struct {
+	// URL to be bookmarked
+	URL string
+	// Comment of the bookmark
+	Comment string
+	// Tags of the bookmark
+	Tags []string
+	// Private makes the bookmark visible only to the user
+	Private bool
+}
+
+// GetBookmark fetches the bookmark of the URL by the user.
+func (c *Client) GetBookmark(ctx context.Context, pageURL string) (*Bookmark, error) {
+	u, err := c.apiURL(url.Values{"url": {pageURL}}, "my", "bookmark")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Bookmark
+	err = c.do(req, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// SaveBookmark adds a bookmark of the URL, or updates it if the URL is
+// already bookmarked by the user.  It returns the bookmark saved by the
+// server.
+func (c *Client) SaveBookmark(ctx context.Context, input SaveBookmarkInput) (*Bookmark, error) {
+	u, err := c.apiURL(nil, "my", "bookmark")
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("url", input.URL)
+	form.Set("comment", input.Comment)
+	for _, tag := range input.Tags {
+		form.Add("tags", tag)
+	}
+	if input.Private {
+		form.Set("private", "1")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var b Bookmark
+	err = c.do(req, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// DeleteBookmark deletes the bookmark of the URL by the user.
+func (c *Client) DeleteBookmark(ctx context.Context, pageURL string) error {
+	u, err := c.apiURL(url.Values{"url": {pageURL}}, "my", "bookmark")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
+// GetEntry fetches the information of the bookmarked page of the URL.
+func (c *Client) GetEntry(ctx context.Context, pageURL string) (*Entry, error) {
+	u, err := c.apiURL(url.Values{"url": {pageURL}}, "entry")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Entry
+	err = c.do(req, &e)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetTags fetches the tags used by the user.
+func (c *Client) GetTags(ctx context.Context) ([]Tag, error) {
+	u, err := c.apiURL(nil, "my", "tags")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Tags []Tag `json:"tags"`
+	}
+	err = c.do(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// apiURL returns an URL of the REST API with the path elements and the
+// query.
+func (c *Client) apiURL(q url.Values, elem ...string) (*url.URL, error) {
+	base := c.BaseURL
+	if len(base) == 0 {
+		base = DefaultBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %q: %w", base, err)
+	}
+	u.Path = path.Join(append([]string{"/", u.Path}, elem...)...)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// do sends the request and decodes the response body into v as JSON.  The
+// response body is discarded if v is nil.
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return apierror.FromResponse(resp)
+	}
+
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,232 @@
+package bookmark
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+	"github.com/ueokande/hatenactl/pkg/hatena/hatenatest"
+	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
+)
+
+const testBookmarkResponse = `{
+  "user": "ueokande",
+  "comment": "nice article",
+  "comment_raw": "[golang][cli]nice article",
+  "tags": ["golang", "cli"],
+  "private": true,
+  "permalink": "https://b.hatena.ne.jp/ueokande/20200301#bookmark-4700000",
+  "created_datetime": "2020-03-01T12:34:56+09:00",
+  "created_epoch": 1583033696
+}`
+
+// newTestServer returns a server authenticating requests by OAuth, and a
+// client signing requests.
+func newTestServer(h http.HandlerFunc) (*httptest.Server, *Client) {
+	token := oauth1.Token{Token: "token", Secret: "token-secret"}
+	auth := hatenatest.OAuth("consumer-key", "consumer-secret", token)
+	server := httptest.NewServer(auth(h))
+	client := &Client{
+		HTTPClient: oauth1.NewHTTPClient("consumer-key", "consumer-secret", token.Token, token.Secret),
+		BaseURL:    server.URL + "/rest/1",
+	}
+	return server, client
+}
+
+func testClientGetBookmark(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/rest/1/my/bookmark" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if v := r.URL.Query().Get("url"); v != "https://example.com/?q=1" {
+			t.Errorf("%q != %q", v, "https://example.com/?q=1")
+		}
+		w.Write([]byte(testBookmarkResponse))
+	})
+	defer server.Close()
+
+	b, err := client.GetBookmark(context.Background(), "https://example.com/?q=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Comment != "nice article" {
+		t.Errorf("%q != %q", b.Comment, "nice article")
+	}
+	if !reflect.DeepEqual(b.Tags, []string{"golang", "cli"}) {
+		t.Errorf("%v != %v", b.Tags, []string{"golang", "cli"})
+	}
+	if !b.Private {
+		t.Error("bookmark is not private")
+	}
+	if b.CreatedAt.Unix() != 1583033696 {
+		t.Errorf("%v != %v", b.CreatedAt.Unix(), 1583033696)
+	}
+}
+
+func testClientSaveBookmark(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/rest/1/my/bookmark" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		err := r.ParseForm()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := r.PostForm.Get("url"); v != "https://example.com/" {
+			t.Errorf("%q != %q", v, "https://example.com/")
+		}
+		if v := r.PostForm.Get("comment"); v != "すごい記事" {
+			t.Errorf("%q != %q", v, "すごい記事")
+		}
+		if v := r.PostForm["tags"]; !reflect.DeepEqual(v, []string{"golang", "cli"}) {
+			t.Errorf("%v != %v", v, []string{"golang", "cli"})
+		}
+		if v := r.PostForm.Get("private"); v != "1" {
+			t.Errorf("%q != %q", v, "1")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(testBookmarkResponse))
+	})
+	defer server.Close()
+
+	b, err := client.SaveBookmark(context.Background(), SaveBookmarkInput{
+		URL:     "https://example.com/",
+		Comment: "すごい記事",
+		Tags:    []string{"golang", "cli"},
+		Private: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.CommentRaw != "[golang][cli]nice article" {
+		t.Errorf("%q != %q", b.CommentRaw, "[golang][cli]nice article")
+	}
+}
+
+func testClientDeleteBookmark(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/rest/1/my/bookmark" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+	defer server.Close()
+
+	err := client.DeleteBookmark(context.Background(), "https://example.com/")
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("%d != %d", apiErr.StatusCode, http.StatusNotFound)
+	}
+}
+
+func testClientGetEntry(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/rest/1/entry" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"title":"Example","url":"https://example.com/","entry_url":"https://b.hatena.ne.jp/entry/s/example.com/","count":42,"favicon_url":"https://cdn-ak.favicon.st-hatena.com/?url=https%3A%2F%2Fexample.com%2F"}`))
+	})
+	defer server.Close()
+
+	e, err := client.GetEntry(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Title != "Example" || e.Count != 42 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func testClientGetTags(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/rest/1/my/tags" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"tags":[{"tag":"golang","count":10,"modified_datetime":"2020-03-01T12:34:56+09:00","modified_epoch":1583033696},{"tag":"cli","count":3,"modified_datetime":"2020-02-01T12:34:56+09:00","modified_epoch":1580528096}]}`))
+	})
+	defer server.Close()
+
+	tags, err := client.GetTags(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("%d != %d", len(tags), 2)
+	}
+	if tags[0].Tag != "golang" || tags[0].Count != 10 {
+		t.Errorf("unexpected tag: %+v", tags[0])
+	}
+}
+
+const testFeedResponse = `<?xml version="1.0" encoding="utf-8"?>
+<feed version="0.3" xmlns="http://purl.org/atom/ns#" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <title>ueokande's bookmarks</title>
+  <link type="text/html" rel="alternate" href="https://b.hatena.ne.jp/ueokande/"/>
+  <link type="application/x.atom+xml" rel="next" href="https://b.hatena.ne.jp/atom/feed?of=20"/>
+  <entry>
+    <title>Example</title>
+    <link type="text/html" rel="related" href="https://example.com/"/>
+    <link type="text/html" rel="alternate" href="https://b.hatena.ne.jp/ueokande/20200301#bookmark-4700000"/>
+    <link type="application/x.atom+xml" rel="service.edit" href="https://b.hatena.ne.jp/atom/edit/4700000" title="Example"/>
+    <issued>2020-03-01T12:34:56+09:00</issued>
+    <id>tag:hatena.ne.jp,2005:bookmark-ueokande-4700000</id>
+    <summary type="text/plain">nice article</summary>
+    <dc:subject>golang</dc:subject>
+    <dc:subject>cli</dc:subject>
+  </entry>
+</feed>`
+
+func testClientListBookmarks(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/atom/feed" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if v := r.URL.Query().Get("of"); v != "20" {
+			t.Errorf("%q != %q", v, "20")
+		}
+		w.Write([]byte(testFeedResponse))
+	})
+	defer server.Close()
+	client.FeedURL = server.URL + "/atom/feed"
+
+	feed, err := client.ListBookmarks(context.Background(), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if of, ok := feed.NextOffset(); !ok || of != 20 {
+		t.Errorf("%v, %v != %v, %v", of, ok, 20, true)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("%d != %d", len(feed.Entries), 1)
+	}
+	e := feed.Entries[0]
+	if e.URL() != "https://example.com/" {
+		t.Errorf("%q != %q", e.URL(), "https://example.com/")
+	}
+	if e.Permalink() != "https://b.hatena.ne.jp/ueokande/20200301#bookmark-4700000" {
+		t.Errorf("%q != %q", e.Permalink(), "https://b.hatena.ne.jp/ueokande/20200301#bookmark-4700000")
+	}
+	if e.Summary != "nice article" {
+		t.Errorf("%q != %q", e.Summary, "nice article")
+	}
+	if !reflect.DeepEqual(e.Tags, []string{"golang", "cli"}) {
+		t.Errorf("%v != %v", e.Tags, []string{"golang", "cli"})
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("GetBookmark", testClientGetBookmark)
+	t.Run("SaveBookmark", testClientSaveBookmark)
+	t.Run("DeleteBookmark", testClientDeleteBookmark)
+	t.Run("GetEntry", testClientGetEntry)
+	t.Run("GetTags", testClientGetTags)
+	t.Run("ListBookmarks", testClientListBookmarks)
+}
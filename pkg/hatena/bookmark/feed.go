@@ -0,0 +1,127 @@
+package bookmark
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+// DefaultFeedURL is an URL of the Atom feed of the bookmarks of the user.
+const DefaultFeedURL = "https://b.hatena.ne.jp/atom/feed"
+
+// A Feed represents a page of the bookmarks of the user in Atom.
+type Feed struct {
+	XMLName xml.Name `xml:"feed"`
+
+	Title   string      `xml:"title"`
+	Links   []Link      `xml:"link"`
+	Entries []FeedEntry `xml:"entry"`
+}
+
+// NextOffset returns an offset of the next page.  It returns false if the
+// next link is not presented.
+func (f *Feed) NextOffset() (int, bool) {
+	for _, l := range f.Links {
+		if l.Rel != "next" {
+			continue
+		}
+		u, err := url.Parse(l.Href)
+		if err != nil {
+			return 0, false
+		}
+		of, err := strconv.Atoi(u.Query().Get("of"))
+		if err != nil {
+			return 0, false
+		}
+		return of, true
+	}
+	return 0, false
+}
+
+// A FeedEntry represents a bookmark in the feed.
+type FeedEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []Link `xml:"link"`
+	// Issued is a time when the URL is bookmarked in RFC 3339
+	Issued string `xml:"issued"`
+	// Summary is a comment of the bookmark
+	Summary string `xml:"summary"`
+	// Tags are tags of the bookmark presented by dc:subject
+	Tags []string `xml:"subject"`
+}
+
+// URL returns the bookmarked URL.  It returns empty string if the related
+// link is not presented.
+func (e FeedEntry) URL() string {
+	return e.link("related")
+}
+
+// Permalink returns an URL of the bookmark on the Hatena Bookmark.  It
+// returns empty string if the alternate link is not presented.
+func (e FeedEntry) Permalink() string {
+	return e.link("alternate")
+}
+
+func (e FeedEntry) link(rel string) string {
+	for _, l := range e.Links {
+		if l.Rel == rel {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// A Link represents a link
+type Link struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+}
+
+// ListBookmarks fetches a page of the bookmarks of the user, from newer
+// ones.  The offset is the number of the bookmarks to skip, which is
+// presented by Feed.NextOffset().  The Atom API of the Hatena Bookmark
+// authenticates requests by WSSE or OAuth.
+func (c *Client) ListBookmarks(ctx context.Context, offset int) (*Feed, error) {
+	feedURL := c.FeedURL
+	if len(feedURL) == 0 {
+		feedURL = DefaultFeedURL
+	}
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url %q: %w", feedURL, err)
+	}
+	if offset > 0 {
+		q := u.Query()
+		q.Set("of", strconv.Itoa(offset))
+		u.RawQuery = q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, apierror.FromResponse(resp)
+	}
+
+	var feed Feed
+	err = xml.NewDecoder(resp.Body).Decode(&feed)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
@@ -0,0 +1,217 @@
+package fotolife
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+// A Client is a client for Hatena Fotolife using Atom API.  The HTTPClient
+// should authenticate requests by wsse.Transport or oauth1.Transport.
+//
+// http://developer.hatena.ne.jp/ja/documents/fotolife/apis/atom
+type Client struct {
+	HTTPClient *http.Client
+
+	// BaseURL is a base URL of the API endpoint.  DefaultBaseURL is used if
+	// empty.
+	BaseURL string
+}
+
+// DefaultBaseURL is a base URL of the Atom API of the Hatena Fotolife.
+const DefaultBaseURL = "https://f.hatena.ne.jp"
+
+// UploadImageInput represents an input parameter of the Client.UploadImage
+type UploadImageInput struct {
+	// Title of the image
+	Title string
+	// Folder where the image is stored.  The image is stored in the top
+	// folder if empty.
+	Folder string
+	// Generator is a name of the tool to upload the image.  The images
+	// uploaded by the same generator are grouped on the fotolife.
+	Generator string
+
+	// ContentType is a MIME type of the image such as "image/png"
+	ContentType string
+	// Body is a content of the image
+	Body io.Reader
+}
+
+// UploadImage uploads a new image, and returns the image created by the
+// server.  Image.Notation() of the returned image presents the syntax to
+// embed the image into the entry.
+func (c *Client) UploadImage(ctx context.Context, input UploadImageInput) (*Image, error) {
+	if input.Body == nil {
+		return nil, errors.New("no image body")
+	}
+	if len(input.ContentType) == 0 {
+		return nil, errors.New("no content type of the image")
+	}
+
+	var buf bytes.Buffer
+	enc := base64.NewEncoder(base64.StdEncoding, &buf)
+	_, err := io.Copy(enc, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := c.apiURL("post")
+	if err != nil {
+		return nil, err
+	}
+	req, err := newImageRequest(ctx, http.MethodPost, u.String(), atomImage{
+		Xmlns: atomNamespace,
+		Dc:    dcNamespace,
+		Title: input.Title,
+		Content: &atomContent{
+			Mode:    "base64",
+			Type:    input.ContentType,
+			Content: buf.String(),
+		},
+		Subject:   input.Folder,
+		Generator: input.Generator,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var image Image
+	err = c.do(req, &image)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ListImages fetches the recent images uploaded by the user.
+func (c *Client) ListImages(ctx context.Context) (*Feed, error) {
+	u, err := c.apiURL("feed")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed Feed
+	err = c.do(req, &feed)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetImage fetches the image by the image ID.
+func (c *Client) GetImage(ctx context.Context, imageID string) (*Image, error) {
+	u, err := c.apiURL("edit", imageID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var image Image
+	err = c.do(req, &image)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// UpdateImageTitle changes the title of the image.
+func (c *Client) UpdateImageTitle(ctx context.Context, imageID string, title string) error {
+	u, err := c.apiURL("edit", imageID)
+	if err != nil {
+		return err
+	}
+	req, err := newImageRequest(ctx, http.MethodPut, u.String(), atomImage{
+		Xmlns: atomNamespace,
+		Title: title,
+	})
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
+// DeleteImage deletes the image.
+func (c *Client) DeleteImage(ctx context.Context, imageID string) error {
+	u, err := c.apiURL("edit", imageID)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
+// apiURL returns an URL of the Atom API with the path elements.
+func (c *Client) apiURL(elem ...string) (*url.URL, error) {
+	base := c.BaseURL
+	if len(base) == 0 {
+		base = DefaultBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %q: %w", base, err)
+	}
+	u.Path = path.Join(append([]string{"/", u.Path, "atom"}, elem...)...)
+	return u, nil
+}
+
+// do sends the request and decodes the response body into v as XML.  The
+// response body is discarded if v is nil.
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return apierror.FromResponse(resp)
+	}
+
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// newImageRequest returns a new request with the image encoded as the Atom
+// XML in the request body.
+func newImageRequest(ctx context.Context, method, url string, image atomImage) (*http.Request, error) {
+	body, err := xml.Marshal(image)
+	if err != nil {
+		return nil, err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x.atom+xml")
+	return req, nil
+}
@@ -0,0 +1,113 @@
+package fotolife
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testImageResponse = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://purl.org/atom/ns#">
+  <title>Sample</title>
+  <link rel="service.edit" type="application/x.atom+xml" href="https://f.hatena.ne.jp/atom/edit/20200301123456" title="Sample"/>
+  <issued>2020-03-01T12:34:56+09:00</issued>
+  <dc:subject xmlns:dc="http://purl.org/dc/elements/1.1/">Hatena Blog</dc:subject>
+  <hatena:syntax xmlns:hatena="http://www.hatena.ne.jp/info/xmlns#">f:id:ueokande:20200301123456p:image</hatena:syntax>
+  <hatena:imageurl xmlns:hatena="http://www.hatena.ne.jp/info/xmlns#">https://cdn-ak.f.st-hatena.com/images/fotolife/u/ueokande/20200301/20200301123456.png</hatena:imageurl>
+</entry>`
+
+func testClientUploadImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/atom/post" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		var body struct {
+			Title   string `xml:"title"`
+			Subject string `xml:"subject"`
+			Content struct {
+				Mode    string `xml:"mode,attr"`
+				Type    string `xml:"type,attr"`
+				Content string `xml:",chardata"`
+			} `xml:"content"`
+		}
+		err := xml.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body.Title != "Sample" || body.Subject != "Hatena Blog" {
+			t.Errorf("unexpected body: %+v", body)
+		}
+		if body.Content.Mode != "base64" || body.Content.Type != "image/png" {
+			t.Errorf("unexpected content: %+v", body.Content)
+		}
+		data, err := base64.StdEncoding.DecodeString(body.Content.Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "\x89PNG" {
+			t.Errorf("%q != %q", data, "\x89PNG")
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(testImageResponse))
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	image, err := c.UploadImage(context.Background(), UploadImageInput{
+		Title:       "Sample",
+		Folder:      "Hatena Blog",
+		ContentType: "image/png",
+		Body:        bytes.NewBufferString("\x89PNG"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.Notation() != "[f:id:ueokande:20200301123456p:image]" {
+		t.Errorf("unexpected notation: %q", image.Notation())
+	}
+	if image.ImageID() != "20200301123456" {
+		t.Errorf("%q != %q", image.ImageID(), "20200301123456")
+	}
+	if image.Folder != "Hatena Blog" {
+		t.Errorf("%q != %q", image.Folder, "Hatena Blog")
+	}
+	if image.ImageURL != "https://cdn-ak.f.st-hatena.com/images/fotolife/u/ueokande/20200301/20200301123456.png" {
+		t.Errorf("unexpected image url: %q", image.ImageURL)
+	}
+}
+
+func testClientUpdateImageTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/atom/edit/20200301123456" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Title string `xml:"title"`
+		}
+		err := xml.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body.Title != "New title" {
+			t.Errorf("%q != %q", body.Title, "New title")
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	err := c.UpdateImageTitle(context.Background(), "20200301123456", "New title")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("UploadImage", testClientUploadImage)
+	t.Run("UpdateImageTitle", testClientUpdateImageTitle)
+}
@@ -0,0 +1,96 @@
+package fotolife
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// A Feed represents a feed with images from fotolife
+type Feed struct {
+	XMLName xml.Name `xml:"feed"`
+
+	Title  string  `xml:"title"`
+	Links  []Link  `xml:"link"`
+	Images []Image `xml:"entry"`
+}
+
+// An Image represents an image uploaded to fotolife.
+type Image struct {
+	ID     string `xml:"id"`
+	Title  string `xml:"title"`
+	Links  []Link `xml:"link"`
+	Issued string `xml:"issued"`
+
+	// Folder is a name of the folder where the image is stored
+	Folder string `xml:"subject"`
+
+	// Syntax is a notation of the image in the Hatena syntax such as
+	// "f:id:ueokande:20200301123456p:image"
+	Syntax string `xml:"syntax"`
+	// ImageURL is an URL of the original image
+	ImageURL string `xml:"imageurl"`
+	// ImageURLSmall is an URL of the thumbnail image
+	ImageURLSmall string `xml:"imageurlsmall"`
+}
+
+// ImageID returns an ID of the image used in the edit URI.  It returns empty
+// string if the edit link is not presented.
+func (i Image) ImageID() string {
+	uri := i.EditURI()
+	if len(uri) == 0 {
+		return ""
+	}
+	return uri[strings.LastIndex(uri, "/")+1:]
+}
+
+// EditURI returns the URI to fetch, update or delete the image.  It returns
+// empty string if the edit link is not presented.
+func (i Image) EditURI() string {
+	for _, l := range i.Links {
+		if l.Rel == "service.edit" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// Notation returns the Hatena syntax of the image to embed it into the
+// entry, such as "[f:id:ueokande:20200301123456p:image]".
+func (i Image) Notation() string {
+	if len(i.Syntax) == 0 {
+		return ""
+	}
+	return "[" + i.Syntax + "]"
+}
+
+// A Link represents a link
+type Link struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+}
+
+const (
+	atomNamespace = "http://purl.org/atom/ns#"
+	dcNamespace   = "http://purl.org/dc/elements/1.1/"
+)
+
+// atomImage represents an image sent to the server on uploading or updating
+// the image.
+type atomImage struct {
+	XMLName xml.Name `xml:"entry"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Dc      string   `xml:"xmlns:dc,attr,omitempty"`
+
+	Title     string       `xml:"title"`
+	Content   *atomContent `xml:"content,omitempty"`
+	Subject   string       `xml:"dc:subject,omitempty"`
+	Generator string       `xml:"generator,omitempty"`
+}
+
+type atomContent struct {
+	Mode    string `xml:"mode,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
@@ -0,0 +1,63 @@
+package hatenatest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
+	"github.com/ueokande/hatenactl/pkg/hatena/wsse"
+)
+
+// WSSE returns a middleware accepting requests with the X-WSSE header of the
+// user.
+func WSSE(username, password string) func(http.Handler) http.Handler {
+	v := &wsse.Verifier{
+		Store: wsseStore{username: username, password: password},
+	}
+	return v.Handler
+}
+
+type wsseStore struct {
+	username string
+	password string
+}
+
+func (s wsseStore) LookupPassword(username string) (string, error) {
+	if username != s.username {
+		return "", errors.New("no such user")
+	}
+	return s.password, nil
+}
+
+// OAuth returns a middleware accepting requests signed by HMAC-SHA1 with the
+// consumer and the token.
+func OAuth(consumerKey, consumerSecret string, token oauth1.Token) func(http.Handler) http.Handler {
+	a := &oauth1.Authenticator{
+		Store: oauthStore{
+			consumerKey:    consumerKey,
+			consumerSecret: consumerSecret,
+			token:          token,
+		},
+	}
+	return a.Handler
+}
+
+type oauthStore struct {
+	consumerKey    string
+	consumerSecret string
+	token          oauth1.Token
+}
+
+func (s oauthStore) LookupConsumer(consumerKey string) (oauth1.Signer, error) {
+	if consumerKey != s.consumerKey {
+		return nil, errors.New("no such consumer")
+	}
+	return &oauth1.HMACSHA1{ConsumerSecret: s.consumerSecret}, nil
+}
+
+func (s oauthStore) LookupToken(consumerKey, token string) (string, error) {
+	if token != s.token.Token {
+		return "", errors.New("no such token")
+	}
+	return s.token.Secret, nil
+}
@@ -0,0 +1,404 @@
+package hatenatest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/blog"
+)
+
+// DefaultPageSize is the default number of the entries in a page of the feed.
+const DefaultPageSize = 10
+
+// A Server is an in-process fake server of the Atom API of the HatenaBlog for
+// testing.  It keeps a blog in memory, and serves the service document, the
+// category document, paged feeds of the entries and CRUD of the entries.
+//
+// The Server also serves static files added by AddFile, to present images in
+// the entries.
+type Server struct {
+	*httptest.Server
+
+	// HatenaID is a hatena ID of the blog owner
+	HatenaID string
+	// BlogID is an ID of the blog
+	BlogID string
+	// Title is a title of the blog
+	Title string
+
+	// PageSize is the number of the entries in a page.  DefaultPageSize is
+	// used if zero.
+	PageSize int
+	// Categories are categories in the category document
+	Categories []string
+	// FixedCategories makes the server to reject categories not in the
+	// Categories
+	FixedCategories bool
+
+	// Auth is a middleware to authenticate requests to the Atom API.  The
+	// requests are not authenticated if nil.
+	Auth func(http.Handler) http.Handler
+
+	// Now returns the current time used as the timestamps of the entries.
+	// time.Now is used if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries []blog.Entry
+	files   map[string]file
+	nextID  int64
+}
+
+type file struct {
+	contentType string
+	body        []byte
+}
+
+// blogNumber is a numeric ID of the blog presented in the entry ID
+const blogNumber = "10000000000000000000"
+
+// NewServer starts and returns a new Server for the blog.  The caller should
+// call Close when finished, to shut it down.
+func NewServer(hatenaID, blogID string) *Server {
+	s := &Server{
+		HatenaID: hatenaID,
+		BlogID:   blogID,
+		Title:    blogID,
+		files:    make(map[string]file),
+		nextID:   1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddEntry adds the entry to the blog as the latest entry, and returns the
+// entry with the ID and links assigned by the server.  The timestamps and
+// the formatted content are filled if empty.
+func (s *Server) AddEntry(entry blog.Entry) blog.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addEntry(entry)
+}
+
+// Entries returns a copy of the entries in the blog from the latest.
+func (s *Server) Entries() []blog.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]blog.Entry, len(s.entries))
+	copy(entries, s.entries)
+	return entries
+}
+
+// AddFile adds a static file served on the path, such as an image in the
+// entries.  It returns the URL of the file.
+func (s *Server) AddFile(p string, contentType string, body []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p = path.Join("/", p)
+	s.files[p] = file{contentType: contentType, body: body}
+	return s.URL + p
+}
+
+func (s *Server) addEntry(entry blog.Entry) blog.Entry {
+	now := s.now()
+	entryID := strconv.FormatInt(s.nextID, 10)
+	s.nextID++
+
+	if entry.Published.IsZero() {
+		entry.Published = now
+	}
+	if entry.Updated.IsZero() {
+		entry.Updated = now
+	}
+	if entry.Edited.IsZero() {
+		entry.Edited = now
+	}
+	if len(entry.Author.Name) == 0 {
+		entry.Author.Name = s.HatenaID
+	}
+	if len(entry.Control.Draft) == 0 {
+		entry.Control.Draft = "no"
+	}
+	if len(entry.FormattedContent.Content) == 0 {
+		entry.FormattedContent = formatContent(entry.Content)
+	}
+
+	entry.ID = blog.EntryID{User: s.HatenaID, Blog: blogNumber, Entry: entryID}.String()
+	entry.Links = []blog.Link{
+		{Rel: "edit", Href: s.collectionURL() + "/" + entryID},
+		{Rel: "alternate", Type: "text/html", Href: s.URL + "/entry/" + entry.Published.Format("2006/01/02/150405")},
+	}
+
+	s.entries = append([]blog.Entry{entry}, s.entries...)
+	return entry
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now().Truncate(time.Second)
+}
+
+func (s *Server) pageSize() int {
+	if s.PageSize <= 0 {
+		return DefaultPageSize
+	}
+	return s.PageSize
+}
+
+func (s *Server) blogPath() string {
+	return path.Join("/", s.HatenaID, s.BlogID, "atom")
+}
+
+func (s *Server) collectionURL() string {
+	return s.URL + path.Join(s.blogPath(), "entry")
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	f, ok := s.files[r.URL.Path]
+	s.mu.Unlock()
+	if ok && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", f.contentType)
+		w.Write(f.body)
+		return
+	}
+
+	if r.URL.Path != s.blogPath() && !strings.HasPrefix(r.URL.Path, s.blogPath()+"/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var h http.Handler = http.HandlerFunc(s.serveAtom)
+	if s.Auth != nil {
+		h = s.Auth(h)
+	}
+	h.ServeHTTP(w, r)
+}
+
+func (s *Server) serveAtom(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, s.blogPath())
+	switch {
+	case p == "" && r.Method == http.MethodGet:
+		s.serveService(w, r)
+	case p == "/category" && r.Method == http.MethodGet:
+		s.serveCategories(w, r)
+	case p == "/entry" && r.Method == http.MethodGet:
+		s.serveFeed(w, r)
+	case p == "/entry" && r.Method == http.MethodPost:
+		s.serveCreateEntry(w, r)
+	case strings.HasPrefix(p, "/entry/"):
+		entryID := strings.TrimPrefix(p, "/entry/")
+		switch r.Method {
+		case http.MethodGet:
+			s.serveGetEntry(w, r, entryID)
+		case http.MethodPut:
+			s.serveUpdateEntry(w, r, entryID)
+		case http.MethodDelete:
+			s.serveDeleteEntry(w, r, entryID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveService(w http.ResponseWriter, r *http.Request) {
+	writeXML(w, http.StatusOK, blog.Service{
+		Workspaces: []blog.Workspace{{
+			Title: s.Title,
+			Collections: []blog.Collection{{
+				Href:    s.collectionURL(),
+				Title:   s.Title + " - Entries",
+				Accepts: []string{"application/atom+xml;type=entry"},
+			}},
+		}},
+	})
+}
+
+func (s *Server) serveCategories(w http.ResponseWriter, r *http.Request) {
+	cats := blog.Categories{Fixed: "no"}
+	if s.FixedCategories {
+		cats.Fixed = "yes"
+	}
+	for _, term := range s.Categories {
+		cats.Categories = append(cats.Categories, blog.Category{Term: term})
+	}
+	writeXML(w, http.StatusOK, cats)
+}
+
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request) {
+	offset := 0
+	if page := r.URL.Query().Get("page"); len(page) > 0 {
+		var err error
+		offset, err = strconv.Atoi(page)
+		if err != nil || offset < 0 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+	}
+
+	first := s.collectionURL()
+	feed := blog.Feed{
+		Xmlns:  "http://www.w3.org/2005/Atom",
+		ID:     "hatenablog://blog/" + blogNumber,
+		Title:  s.Title,
+		Author: blog.Author{Name: s.HatenaID},
+		Links:  []blog.Link{{Rel: "first", Href: first}},
+	}
+	end := offset + s.pageSize()
+	if end < len(s.entries) {
+		feed.Links = append(feed.Links, blog.Link{Rel: "next", Href: first + "?page=" + strconv.Itoa(end)})
+	} else {
+		end = len(s.entries)
+	}
+	if offset < end {
+		feed.Entries = s.entries[offset:end]
+	}
+	writeXML(w, http.StatusOK, feed)
+}
+
+func (s *Server) serveCreateEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.decodeEntry(w, r)
+	if !ok {
+		return
+	}
+	entry = s.addEntry(entry)
+	writeXML(w, http.StatusCreated, atomEntry{Entry: entry})
+}
+
+func (s *Server) serveGetEntry(w http.ResponseWriter, r *http.Request, entryID string) {
+	i := s.indexOf(entryID)
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	writeXML(w, http.StatusOK, atomEntry{Entry: s.entries[i]})
+}
+
+func (s *Server) serveUpdateEntry(w http.ResponseWriter, r *http.Request, entryID string) {
+	i := s.indexOf(entryID)
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	entry, ok := s.decodeEntry(w, r)
+	if !ok {
+		return
+	}
+
+	old := s.entries[i]
+	old.Title = entry.Title
+	old.Content = entry.Content
+	old.FormattedContent = formatContent(entry.Content)
+	old.Categories = entry.Categories
+	if len(entry.Control.Draft) > 0 {
+		old.Control.Draft = entry.Control.Draft
+	}
+	if !entry.Updated.IsZero() {
+		old.Updated = entry.Updated
+	}
+	old.Edited = s.now()
+	s.entries[i] = old
+
+	writeXML(w, http.StatusOK, atomEntry{Entry: old})
+}
+
+func (s *Server) serveDeleteEntry(w http.ResponseWriter, r *http.Request, entryID string) {
+	i := s.indexOf(entryID)
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	w.WriteHeader(http.StatusOK)
+}
+
+// decodeEntry decodes the entry in the request body.  It writes an error
+// response and returns false if the entry is invalid.
+func (s *Server) decodeEntry(w http.ResponseWriter, r *http.Request) (blog.Entry, bool) {
+	var entry blog.Entry
+	err := xml.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		http.Error(w, "invalid entry: "+err.Error(), http.StatusBadRequest)
+		return blog.Entry{}, false
+	}
+	if s.FixedCategories {
+		cats := blog.Categories{Fixed: "yes"}
+		for _, term := range s.Categories {
+			cats.Categories = append(cats.Categories, blog.Category{Term: term})
+		}
+		for _, c := range entry.Categories {
+			if !cats.Allows(c.Term) {
+				http.Error(w, fmt.Sprintf("unknown category %q", c.Term), http.StatusBadRequest)
+				return blog.Entry{}, false
+			}
+		}
+	}
+	return entry, true
+}
+
+func (s *Server) indexOf(entryID string) int {
+	for i, e := range s.entries {
+		id, err := e.EntryID()
+		if err == nil && id.Entry == entryID {
+			return i
+		}
+	}
+	return -1
+}
+
+// atomEntry is an entry in the response body
+type atomEntry struct {
+	XMLName xml.Name `xml:"entry"`
+	Xmlns   string   `xml:"xmlns,attr"`
+
+	blog.Entry
+}
+
+// formatContent converts the content into the HTML as the formatted content.
+func formatContent(content blog.Content) blog.Content {
+	if content.Type == "text/html" {
+		return content
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(content.Content, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		b.WriteString("<p>" + html.EscapeString(line) + "</p>")
+	}
+	return blog.Content{Type: "text/html", Content: b.String()}
+}
+
+func writeXML(w http.ResponseWriter, code int, v interface{}) {
+	if e, ok := v.(atomEntry); ok {
+		e.Xmlns = "http://www.w3.org/2005/Atom"
+		v = e
+	}
+	body, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
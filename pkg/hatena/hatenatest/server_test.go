@@ -0,0 +1,214 @@
+package hatenatest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+	"github.com/ueokande/hatenactl/pkg/hatena/blog"
+	"github.com/ueokande/hatenactl/pkg/hatena/oauth1"
+	"github.com/ueokande/hatenactl/pkg/hatena/wsse"
+)
+
+func testServerCRUD(t *testing.T) {
+	server := NewServer("ueokande", "ueokande.hatenablog.com")
+	defer server.Close()
+	server.Auth = WSSE("ueokande", "secret")
+
+	ctx := context.Background()
+	c := &blog.Client{
+		HTTPClient: wsse.NewHTTPClient("ueokande", "secret"),
+		BaseURL:    server.URL,
+	}
+
+	created, err := c.CreateEntry(ctx, blog.CreateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		Entry: blog.Entry{
+			Title:   "Greeting",
+			Content: blog.Content{Type: "text/x-markdown", Content: "Hello, world"},
+			Control: blog.Control{Draft: "yes"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := created.EntryID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := c.UpdateEntry(ctx, blog.UpdateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  id.Entry,
+		Entry: blog.Entry{
+			Title:   "Greeting (updated)",
+			Content: blog.Content{Type: "text/x-markdown", Content: "Hello, world"},
+			Control: blog.Control{Draft: "no"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "Greeting (updated)" || updated.Control.Draft != "no" {
+		t.Errorf("unexpected entry: %+v", updated)
+	}
+
+	got, err := c.GetEntry(ctx, blog.GetEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  id.Entry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Greeting (updated)" {
+		t.Errorf("%q != %q", got.Title, "Greeting (updated)")
+	}
+	if got.FormattedContent.Content != "<p>Hello, world</p>" {
+		t.Errorf("unexpected formatted content: %q", got.FormattedContent.Content)
+	}
+
+	err = c.DeleteEntry(ctx, blog.DeleteEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  id.Entry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetEntry(ctx, blog.GetEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		EntryID:  id.Entry,
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testServerPaging(t *testing.T) {
+	server := NewServer("ueokande", "ueokande.hatenablog.com")
+	defer server.Close()
+	server.PageSize = 2
+	for i := 0; i < 5; i++ {
+		server.AddEntry(blog.Entry{Title: "entry"})
+	}
+
+	c := &blog.Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	it := c.Entries(context.Background(), blog.EntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Entry().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 5 {
+		t.Fatalf("%d != %d", len(ids), 5)
+	}
+	for i, e := range server.Entries() {
+		if ids[i] != e.ID {
+			t.Errorf("%q != %q", ids[i], e.ID)
+		}
+	}
+}
+
+func testServerUnauthorized(t *testing.T) {
+	server := NewServer("ueokande", "ueokande.hatenablog.com")
+	defer server.Close()
+	server.Auth = WSSE("ueokande", "secret")
+
+	c := &blog.Client{
+		HTTPClient: wsse.NewHTTPClient("ueokande", "wrong"),
+		BaseURL:    server.URL,
+	}
+	_, err := c.ListEntries(context.Background(), blog.ListEntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testServerOAuth(t *testing.T) {
+	server := NewServer("ueokande", "ueokande.hatenablog.com")
+	defer server.Close()
+	server.Auth = OAuth("consumer", "consumer-secret", oauth1.Token{Token: "token", Secret: "token-secret"})
+	server.AddEntry(blog.Entry{Title: "Greeting"})
+
+	c := &blog.Client{
+		HTTPClient: oauth1.NewHTTPClient("consumer", "consumer-secret", "token", "token-secret"),
+		BaseURL:    server.URL,
+	}
+	feed, err := c.ListEntries(context.Background(), blog.ListEntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Errorf("%d != %d", len(feed.Entries), 1)
+	}
+
+	c.HTTPClient = oauth1.NewHTTPClient("consumer", "consumer-secret", "token", "wrong")
+	_, err = c.ListEntries(context.Background(), blog.ListEntriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func testServerCategories(t *testing.T) {
+	server := NewServer("ueokande", "ueokande.hatenablog.com")
+	defer server.Close()
+	server.Categories = []string{"Go", "Vim"}
+	server.FixedCategories = true
+
+	ctx := context.Background()
+	c := &blog.Client{HTTPClient: server.Client(), BaseURL: server.URL}
+	cats, err := c.GetCategories(ctx, blog.GetCategoriesInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cats.Allows("Go") || cats.Allows("Rust") {
+		t.Errorf("unexpected categories: %+v", cats)
+	}
+
+	_, err = c.CreateEntry(ctx, blog.CreateEntryInput{
+		HatenaID: "ueokande",
+		BlogID:   "ueokande.hatenablog.com",
+		Entry: blog.Entry{
+			Title:      "Rust",
+			Categories: []blog.Category{{Term: "Rust"}},
+		},
+	})
+	if err == nil {
+		t.Error("expected error on unknown category")
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Run("CRUD", testServerCRUD)
+	t.Run("Paging", testServerPaging)
+	t.Run("Unauthorized", testServerUnauthorized)
+	t.Run("OAuth", testServerOAuth)
+	t.Run("Categories", testServerCategories)
+}
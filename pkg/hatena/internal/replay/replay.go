@@ -0,0 +1,70 @@
+// Package replay provides a bounded cache of nonces to detect replayed
+// requests.
+package replay
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultSize is the default maximum number of the nonces in the Cache.
+const DefaultSize = 10000
+
+// A Cache is a bounded set of nonces seen recently.  A nonce is kept until
+// it expires or the cache is full.  The oldest nonce is evicted when the
+// cache is full.
+type Cache struct {
+	// Size is the maximum number of the nonces.  DefaultSize is used if
+	// zero.
+	Size int
+	// TTL is a duration to keep the nonce
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   list.List
+}
+
+type entry struct {
+	key     string
+	expires time.Time
+}
+
+// Seen records the nonce at the time now, and returns true if the nonce is
+// already seen and not expired.
+func (c *Cache) Seen(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+
+	// evict expired entries from the oldest
+	for e := c.order.Front(); e != nil; e = c.order.Front() {
+		if e.Value.(*entry).expires.After(now) {
+			break
+		}
+		c.remove(e)
+	}
+
+	if _, ok := c.entries[nonce]; ok {
+		return true
+	}
+
+	size := c.Size
+	if size <= 0 {
+		size = DefaultSize
+	}
+	for c.order.Len() >= size {
+		c.remove(c.order.Front())
+	}
+	c.entries[nonce] = c.order.PushBack(&entry{key: nonce, expires: now.Add(c.TTL)})
+	return false
+}
+
+func (c *Cache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.entries, e.Value.(*entry).key)
+}
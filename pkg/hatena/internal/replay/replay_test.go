@@ -0,0 +1,27 @@
+package replay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	c := &Cache{Size: 2, TTL: time.Minute}
+
+	if c.Seen("a", now) {
+		t.Error("a must not be seen")
+	}
+	if !c.Seen("a", now.Add(time.Second)) {
+		t.Error("a must be seen")
+	}
+	if c.Seen("a", now.Add(2*time.Minute)) {
+		t.Error("a must be expired")
+	}
+
+	c.Seen("b", now.Add(2*time.Minute))
+	c.Seen("c", now.Add(2*time.Minute))
+	if c.Seen("a", now.Add(2*time.Minute)) {
+		t.Error("a must be evicted by the size")
+	}
+}
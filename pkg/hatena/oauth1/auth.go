@@ -9,9 +9,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
 )
 
 // CallbackOOB means the client is unable to receive callbacks (out-of-band).
@@ -32,6 +35,16 @@ type Client struct {
 	TemporaryCredentialURI        string
 	ResourceOwnerAuthorizationURI string
 	TokenRequestURI               string
+
+	// HTTPClient is a client to send requests.  http.DefaultClient is used
+	// if nil.
+	HTTPClient *http.Client
+	// Clock returns the current time used as the timestamp.  time.Now is
+	// used if nil.
+	Clock func() time.Time
+	// NonceFunc returns a nonce of the request.  A random string is used if
+	// nil.
+	NonceFunc func() string
 }
 
 // Initiate gets a temporary credential from a service provider, and returns
@@ -45,16 +58,16 @@ type Client struct {
 // See: RFC 5849 - 2.1 Temporary Credentials
 func (c *Client) Initiate(ctx context.Context, callbackURL string, params url.Values) (Token, error) {
 	body := bytes.NewBufferString(params.Encode())
-	req, err := http.NewRequest(http.MethodPost, c.TemporaryCredentialURI, body)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TemporaryCredentialURI, body)
 	if err != nil {
 		return Token{}, err
 	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	oauthParams := map[string]string{
 		"oauth_consumer_key":     c.ConsumerKey,
-		"oauth_nonce":            nonce(),
-		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_nonce":            newNonce(c.NonceFunc),
+		"oauth_timestamp":        timestamp(c.Clock),
 		"oauth_signature_method": c.Signer.Method(),
 		"oauth_version":          "1.0",
 		"oauth_callback":         callbackURL,
@@ -64,19 +77,19 @@ func (c *Client) Initiate(ctx context.Context, callbackURL string, params url.Va
 
 	req.Header.Set("Authorization", oauthAuthorizationHeaderValue(oauthParams))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return Token{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != 200 {
+		return Token{}, apierror.FromResponse(resp)
+	}
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return Token{}, err
 	}
-	if resp.StatusCode != 200 {
-		return Token{}, fmt.Errorf("server returns %d (%s): %s", resp.StatusCode, resp.Status, respBody)
-	}
 	vals, err := url.ParseQuery(string(respBody))
 	if err != nil {
 		return Token{}, err
@@ -120,15 +133,15 @@ func (c *Client) GetAuthorizeURL(ctx context.Context, token string) (string, err
 //
 // See: RFC 5849 - 2.3 Token Credentials
 func (c *Client) GetAccessToken(ctx context.Context, token Token, verifier string) (Token, error) {
-	req, err := http.NewRequest(http.MethodPost, c.TokenRequestURI, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenRequestURI, nil)
 	if err != nil {
 		return Token{}, err
 	}
 
 	oauthParams := map[string]string{
 		"oauth_consumer_key":     c.ConsumerKey,
-		"oauth_nonce":            nonce(),
-		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_nonce":            newNonce(c.NonceFunc),
+		"oauth_timestamp":        timestamp(c.Clock),
 		"oauth_token":            token.Token,
 		"oauth_verifier":         verifier,
 		"oauth_signature_method": c.Signer.Method(),
@@ -139,19 +152,19 @@ func (c *Client) GetAccessToken(ctx context.Context, token Token, verifier strin
 
 	req.Header.Set("Authorization", oauthAuthorizationHeaderValue(oauthParams))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return Token{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != 200 {
+		return Token{}, apierror.FromResponse(resp)
+	}
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return Token{}, err
 	}
-	if resp.StatusCode != 200 {
-		return Token{}, fmt.Errorf("server returns %d (%s): %s", resp.StatusCode, resp.Status, respBody)
-	}
 
 	vals, err := url.ParseQuery(string(respBody))
 	if err != nil {
@@ -171,6 +184,31 @@ func (c *Client) GetAccessToken(ctx context.Context, token Token, verifier strin
 	return token, nil
 }
 
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// newNonce returns a nonce by the fn, or a random nonce if fn is nil.
+func newNonce(fn func() string) string {
+	if fn != nil {
+		return fn()
+	}
+	return nonce()
+}
+
+// timestamp returns the current time by the clock in the seconds since
+// epoch.  The time.Now is used if clock is nil.
+func timestamp(clock func() time.Time) string {
+	now := time.Now
+	if clock != nil {
+		now = clock
+	}
+	return strconv.FormatInt(now().Unix(), 10)
+}
+
 // nonce generate a random bytes with length of 16 bytes with HEX-encoded.
 func nonce() string {
 	var nonce [16]byte
@@ -182,11 +220,25 @@ func nonce() string {
 }
 
 // oauthAuthorizationHeaderValue presents a value of "Authorization" header
-// from the params.
+// from the params.  The realm comes first, and the others are sorted by the
+// key.
+//
+// See: RFC 5849 - 3.5.1. Authorization Header
 func oauthAuthorizationHeaderValue(params map[string]string) string {
+	var keys []string
+	for k := range params {
+		if k != "realm" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := params["realm"]; ok {
+		keys = append([]string{"realm"}, keys...)
+	}
+
 	var kvs []string
-	for k, v := range params {
-		kvs = append(kvs, url.QueryEscape(k)+"=\""+url.QueryEscape(v)+"\"")
+	for _, k := range keys {
+		kvs = append(kvs, percentEncode(k)+"=\""+percentEncode(params[k])+"\"")
 	}
 	return "OAuth " + strings.Join(kvs, ", ")
 }
@@ -0,0 +1,164 @@
+package oauth1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// testVerifyRequest verifies the signature of the request received by the
+// server.  It is called in the handler goroutine, so it reports errors by
+// t.Error and returns nil on failure.
+func testVerifyRequest(t *testing.T, r *http.Request, signer Verifier, tokenSecret string) map[string]string {
+	params, err := parseAuthorizationHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	sig := params["oauth_signature"]
+	delete(params, "oauth_signature")
+	delete(params, "realm")
+
+	bodyParams, err := requestBodyParams(r)
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	r.URL.Scheme = "http"
+	r.URL.Host = r.Host
+	err = signer.Verify(tokenSecret, signatureText(r, params, bodyParams), sig)
+	if err != nil {
+		t.Errorf("signature mismatch: %v", err)
+	}
+	return params
+}
+
+func testClientInitiate(t *testing.T) {
+	signer := &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := testVerifyRequest(t, r, signer, "")
+		if params["oauth_timestamp"] != "137131200" {
+			t.Errorf("%q != %q", params["oauth_timestamp"], "137131200")
+		}
+		if params["oauth_nonce"] != "wIjqoS" {
+			t.Errorf("%q != %q", params["oauth_nonce"], "wIjqoS")
+		}
+		if params["oauth_callback"] != "http://printer.example.com/ready" {
+			t.Errorf("%q != %q", params["oauth_callback"], "http://printer.example.com/ready")
+		}
+		if r.FormValue("scope") != "read_public write_public" {
+			t.Errorf("%q != %q", r.FormValue("scope"), "read_public write_public")
+		}
+		w.Write([]byte("oauth_token=hh5s93j4hdidpola&oauth_token_secret=hdhd0244k9j7ao03&oauth_callback_confirmed=true"))
+	}))
+	defer server.Close()
+
+	c := &Client{
+		ConsumerKey:            "dpf43f3p2l4k3l03",
+		Signer:                 signer,
+		TemporaryCredentialURI: server.URL + "/initiate",
+		HTTPClient:             server.Client(),
+		Clock:                  func() time.Time { return time.Unix(137131200, 0) },
+		NonceFunc:              func() string { return "wIjqoS" },
+	}
+	q := url.Values{}
+	q.Set("scope", "read_public write_public")
+	token, err := c.Initiate(context.Background(), "http://printer.example.com/ready", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "hh5s93j4hdidpola" || token.Secret != "hdhd0244k9j7ao03" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+}
+
+func testClientGetAccessToken(t *testing.T) {
+	signer := &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := testVerifyRequest(t, r, signer, "hdhd0244k9j7ao03")
+		if params["oauth_verifier"] != "hfdp7dh39dks9884" {
+			t.Errorf("%q != %q", params["oauth_verifier"], "hfdp7dh39dks9884")
+		}
+		w.Write([]byte("oauth_token=nnch734d00sl2jdk&oauth_token_secret=pfkkdhi9sl3r4s00"))
+	}))
+	defer server.Close()
+
+	c := &Client{
+		ConsumerKey:     "dpf43f3p2l4k3l03",
+		Signer:          signer,
+		TokenRequestURI: server.URL + "/token",
+		HTTPClient:      server.Client(),
+		Clock:           func() time.Time { return time.Unix(137131201, 0) },
+		NonceFunc:       func() string { return "walatlh" },
+	}
+	token, err := c.GetAccessToken(context.Background(), Token{
+		Token:  "hh5s93j4hdidpola",
+		Secret: "hdhd0244k9j7ao03",
+	}, "hfdp7dh39dks9884")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "nnch734d00sl2jdk" || token.Secret != "pfkkdhi9sl3r4s00" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+}
+
+func testClientCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not be sent")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := &Client{
+		ConsumerKey:            "dpf43f3p2l4k3l03",
+		Signer:                 &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+		TemporaryCredentialURI: server.URL + "/initiate",
+		HTTPClient:             server.Client(),
+	}
+	_, err := c.Initiate(ctx, CallbackOOB, nil)
+	if err == nil {
+		t.Error("expected error on canceled context")
+	}
+}
+
+func testTransportSignFormBody(t *testing.T) {
+	signer := &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testVerifyRequest(t, r, signer, "pfkkdhi9sl3r4s00")
+		if r.FormValue("title") != "日本語 タイトル" {
+			t.Errorf("%q != %q", r.FormValue("title"), "日本語 タイトル")
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			ConsumerKey: "dpf43f3p2l4k3l03",
+			OAuthToken:  Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"},
+			Signer:      signer,
+		},
+	}
+	form := url.Values{}
+	form.Set("title", "日本語 タイトル")
+	resp, err := client.PostForm(server.URL+"/post?a=b+c", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Initiate", testClientInitiate)
+	t.Run("GetAccessToken", testClientGetAccessToken)
+	t.Run("Cancel", testClientCancel)
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("SignFormBody", testTransportSignFormBody)
+}
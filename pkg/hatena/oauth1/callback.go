@@ -0,0 +1,122 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// callbackPath is a path of the callback URL in the CallbackServer
+const callbackPath = "/callback"
+
+// A CallbackServer is a temporary HTTP server on the loopback interface to
+// receive the verifier from the service provider.  Pass URL() as the
+// callbackURL of Client.Initiate, and call Expect with the temporary token
+// before the authorization.  The server picks up the oauth_verifier when the
+// resource owner is redirected back on the Resource Owner Authorization.
+//
+// See: RFC 5849 - 2.2 Resource Owner Authorization
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	results  chan callbackResult
+
+	mu    sync.Mutex
+	token string
+}
+
+type callbackResult struct {
+	token    string
+	verifier string
+}
+
+// NewCallbackServer starts a new CallbackServer listening on the addr, such
+// as "127.0.0.1:0".  The caller should call Close when finished.
+func NewCallbackServer(addr string) (*CallbackServer, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &CallbackServer{
+		listener: l,
+		results:  make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, s.handleCallback)
+	s.server = &http.Server{Handler: mux}
+
+	go s.server.Serve(l)
+	return s, nil
+}
+
+// URL returns the callback URL of the server.
+func (s *CallbackServer) URL() string {
+	return "http://" + s.listener.Addr().String() + callbackPath
+}
+
+// Expect sets the temporary token to be received.  The server rejects
+// callbacks with other tokens.
+func (s *CallbackServer) Expect(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// Wait waits for the resource owner to be redirected with the token, and
+// returns the verifier.  It returns an error if the ctx is done before the
+// redirection.
+func (s *CallbackServer) Wait(ctx context.Context, token string) (string, error) {
+	s.Expect(token)
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no callback received: %w", ctx.Err())
+		case r := <-s.results:
+			if r.token == token {
+				return r.verifier, nil
+			}
+		}
+	}
+}
+
+// Close shuts down the server gracefully.
+func (s *CallbackServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return s.server.Close()
+	}
+	return err
+}
+
+func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	token := q.Get("oauth_token")
+	verifier := q.Get("oauth_verifier")
+	if len(token) == 0 || len(verifier) == 0 {
+		http.Error(w, "Authorization failed: no oauth_token or oauth_verifier", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	expected := s.token
+	s.mu.Unlock()
+	if token != expected {
+		http.Error(w, "Authorization failed: unexpected oauth_token", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case s.results <- callbackResult{token: token, verifier: verifier}:
+	default:
+		// Another callback is waiting to be received
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "Authorization succeeded.  You can close this window.")
+}
@@ -0,0 +1,111 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func testCallbackServerWait(t *testing.T) {
+	cs, err := NewCallbackServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	// A fake authorization endpoint redirecting the resource owner to the
+	// callback URL
+	authz := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := url.Values{}
+		q.Set("oauth_token", r.URL.Query().Get("oauth_token"))
+		q.Set("oauth_verifier", "hfdp7dh39dks9884")
+		http.Redirect(w, r, cs.URL()+"?"+q.Encode(), http.StatusFound)
+	}))
+	defer authz.Close()
+
+	cs.Expect("hh5s93j4hdidpola")
+	go func() {
+		resp, err := http.Get(authz.URL + "?oauth_token=hh5s93j4hdidpola")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%d != %d", resp.StatusCode, http.StatusOK)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	verifier, err := cs.Wait(ctx, "hh5s93j4hdidpola")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifier != "hfdp7dh39dks9884" {
+		t.Errorf("%q != %q", verifier, "hfdp7dh39dks9884")
+	}
+}
+
+func testCallbackServerTimeout(t *testing.T) {
+	cs, err := NewCallbackServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cs.Wait(ctx, "hh5s93j4hdidpola")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = cs.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = http.Get(cs.URL())
+	if err == nil {
+		t.Error("server must be shut down")
+	}
+}
+
+func testCallbackServerBadRequest(t *testing.T) {
+	cs, err := NewCallbackServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	cs.Expect("hh5s93j4hdidpola")
+	for _, q := range []string{
+		"?oauth_token=hh5s93j4hdidpola",
+		"?oauth_token=unknown&oauth_verifier=hfdp7dh39dks9884",
+	} {
+		resp, err := http.Get(cs.URL() + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%d != %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cs.Wait(ctx, "hh5s93j4hdidpola")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("the unexpected token must not be received: %v", err)
+	}
+}
+
+func TestCallbackServer(t *testing.T) {
+	t.Run("Wait", testCallbackServerWait)
+	t.Run("Timeout", testCallbackServerTimeout)
+	t.Run("BadRequest", testCallbackServerBadRequest)
+}
@@ -2,7 +2,6 @@ package oauth1
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -12,19 +11,34 @@ type Transport struct {
 	OAuthToken  Token
 	Signer      Signer
 
+	// Clock returns the current time used as the timestamp.  time.Now is
+	// used if nil.
+	Clock func() time.Time
+	// NonceFunc returns a nonce of the request.  A random string is used if
+	// nil.
+	NonceFunc func() string
+
 	http.RoundTripper
 }
 
 func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// RoundTripper should not modify the request
+	r = r.Clone(r.Context())
+
+	bodyParams, err := requestBodyParams(r)
+	if err != nil {
+		return nil, err
+	}
+
 	oauthParams := map[string]string{
 		"oauth_consumer_key":     t.ConsumerKey,
-		"oauth_nonce":            nonce(),
-		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_nonce":            newNonce(t.NonceFunc),
+		"oauth_timestamp":        timestamp(t.Clock),
 		"oauth_token":            t.OAuthToken.Token,
 		"oauth_signature_method": t.Signer.Method(),
 		"oauth_version":          "1.0",
 	}
-	oauthParams["oauth_signature"] = t.Signer.Sign(t.OAuthToken.Secret, signatureText(r, oauthParams, r.URL.Query()))
+	oauthParams["oauth_signature"] = t.Signer.Sign(t.OAuthToken.Secret, signatureText(r, oauthParams, bodyParams))
 	oauthParams["realm"] = t.Realm
 
 	r.Header.Set("Authorization", oauthAuthorizationHeaderValue(oauthParams))
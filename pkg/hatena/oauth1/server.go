@@ -0,0 +1,262 @@
+package oauth1
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/internal/replay"
+)
+
+// DefaultMaxSkew is the default maximum difference of the timestamp in the
+// request from the current time.
+const DefaultMaxSkew = 5 * time.Minute
+
+// A CredentialStore is an interface to look up credentials of the consumers
+// and the tokens on verifying requests.
+type CredentialStore interface {
+	// LookupConsumer returns the Signer of the consumer to verify the
+	// signature, such as HMACSHA1 with the consumer secret.
+	LookupConsumer(consumerKey string) (Signer, error)
+
+	// LookupToken returns the secret of the token issued to the consumer.
+	LookupToken(consumerKey, token string) (string, error)
+}
+
+// Authorization represents the consumer and the token of the authenticated
+// request.
+type Authorization struct {
+	ConsumerKey string
+	Token       string
+}
+
+type authorizationContextKey struct{}
+
+// AuthorizationFromContext returns the Authorization of the request
+// authenticated by the Authenticator.
+func AuthorizationFromContext(ctx context.Context) (Authorization, bool) {
+	a, ok := ctx.Value(authorizationContextKey{}).(Authorization)
+	return a, ok
+}
+
+// An Authenticator verifies requests signed by the OAuth 1.0 clients.  It
+// parses the Authorization header, and recomputes the signature with the
+// Signer of the consumer.  It rejects requests with stale timestamps and
+// replayed nonces.
+//
+// The signature base string is built from the Host header and the scheme of
+// the connection.
+//
+// See: RFC 5849 - 3.2 Verifying Requests
+type Authenticator struct {
+	// Realm is a realm presented in WWW-Authenticate header on errors
+	Realm string
+	// Store looks up the credentials
+	Store CredentialStore
+	// AllowNoToken accepts requests signed without a token, such as
+	// requests for temporary credentials
+	AllowNoToken bool
+
+	// MaxSkew is the maximum difference of the timestamp from the current
+	// time.  DefaultMaxSkew is used if zero.
+	MaxSkew time.Duration
+	// NonceCacheSize is the maximum number of the nonces remembered to
+	// reject replayed requests.  replay.DefaultSize is used if zero.
+	NonceCacheSize int
+	// Clock returns the current time.  time.Now is used if nil.
+	Clock func() time.Time
+
+	once   sync.Once
+	nonces *replay.Cache
+}
+
+// Handler returns a middleware to authenticate requests.  It responds 401
+// Unauthorized on failures, or calls the next handler with the Authorization
+// in the request context.
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("OAuth realm=%q", a.Realm))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), authorizationContextKey{}, auth)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate verifies the request, and returns the Authorization of the
+// request.
+func (a *Authenticator) Authenticate(r *http.Request) (Authorization, error) {
+	params, err := parseAuthorizationHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		return Authorization{}, err
+	}
+	signature := params["oauth_signature"]
+	delete(params, "oauth_signature")
+	delete(params, "realm")
+
+	auth := Authorization{
+		ConsumerKey: params["oauth_consumer_key"],
+		Token:       params["oauth_token"],
+	}
+	if len(auth.ConsumerKey) == 0 {
+		return Authorization{}, errors.New("no oauth_consumer_key")
+	}
+	if len(signature) == 0 {
+		return Authorization{}, errors.New("no oauth_signature")
+	}
+	if v, ok := params["oauth_version"]; ok && v != "1.0" {
+		return Authorization{}, fmt.Errorf("unsupported oauth_version %q", v)
+	}
+	if len(auth.Token) == 0 && !a.AllowNoToken {
+		return Authorization{}, errors.New("no oauth_token")
+	}
+
+	signer, err := a.Store.LookupConsumer(auth.ConsumerKey)
+	if err != nil {
+		return Authorization{}, fmt.Errorf("unknown consumer: %w", err)
+	}
+	if params["oauth_signature_method"] != signer.Method() {
+		return Authorization{}, fmt.Errorf("unsupported oauth_signature_method %q", params["oauth_signature_method"])
+	}
+	var tokenSecret string
+	if len(auth.Token) > 0 {
+		tokenSecret, err = a.Store.LookupToken(auth.ConsumerKey, auth.Token)
+		if err != nil {
+			return Authorization{}, fmt.Errorf("unknown token: %w", err)
+		}
+	}
+
+	// PLAINTEXT has no timestamp and nonce
+	if signer.Method() != "PLAINTEXT" {
+		if len(params["oauth_timestamp"]) == 0 {
+			return Authorization{}, errors.New("no oauth_timestamp")
+		}
+		if len(params["oauth_nonce"]) == 0 {
+			return Authorization{}, errors.New("no oauth_nonce")
+		}
+	}
+	if len(params["oauth_timestamp"]) > 0 {
+		err = a.checkTimestamp(params["oauth_timestamp"])
+		if err != nil {
+			return Authorization{}, err
+		}
+	}
+
+	bodyParams, err := requestBodyParams(r)
+	if err != nil {
+		return Authorization{}, err
+	}
+	u := *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	req := &http.Request{Method: r.Method, URL: &u}
+	text := signatureText(req, params, bodyParams)
+
+	if v, ok := signer.(Verifier); ok {
+		err = v.Verify(tokenSecret, text, signature)
+	} else if subtle.ConstantTimeCompare([]byte(signer.Sign(tokenSecret, text)), []byte(signature)) != 1 {
+		err = ErrInvalidSignature
+	}
+	if err != nil {
+		return Authorization{}, err
+	}
+
+	// Remember the nonce only for valid requests, to prevent others from
+	// exhausting nonces
+	if len(params["oauth_nonce"]) > 0 || signer.Method() != "PLAINTEXT" {
+		key := strings.Join([]string{auth.ConsumerKey, auth.Token, params["oauth_timestamp"], params["oauth_nonce"]}, "&")
+		if a.nonceCache().Seen(key, a.now()) {
+			return Authorization{}, errors.New("oauth_nonce already used")
+		}
+	}
+	return auth, nil
+}
+
+func (a *Authenticator) checkTimestamp(v string) error {
+	ts, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid oauth_timestamp %q", v)
+	}
+	d := a.now().Sub(time.Unix(ts, 0))
+	if d < 0 {
+		d = -d
+	}
+	if d > a.maxSkew() {
+		return fmt.Errorf("stale oauth_timestamp %q", v)
+	}
+	return nil
+}
+
+func (a *Authenticator) nonceCache() *replay.Cache {
+	a.once.Do(func() {
+		// Nonces are kept while the timestamp is acceptable
+		a.nonces = &replay.Cache{Size: a.NonceCacheSize, TTL: 2 * a.maxSkew()}
+	})
+	return a.nonces
+}
+
+func (a *Authenticator) maxSkew() time.Duration {
+	if a.MaxSkew <= 0 {
+		return DefaultMaxSkew
+	}
+	return a.MaxSkew
+}
+
+func (a *Authenticator) now() time.Time {
+	if a.Clock != nil {
+		return a.Clock()
+	}
+	return time.Now()
+}
+
+// parseAuthorizationHeader parses a value of "Authorization" header and
+// returns the parameters.
+//
+// See: RFC 5849 - 3.5.1. Authorization Header
+func parseAuthorizationHeader(v string) (map[string]string, error) {
+	if len(v) < 6 || !strings.EqualFold(v[:6], "OAuth ") {
+		return nil, errors.New("no OAuth authorization header")
+	}
+
+	params := make(map[string]string)
+	for _, kv := range strings.Split(v[6:], ",") {
+		kv = strings.TrimSpace(kv)
+		if len(kv) == 0 {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid parameter %q", kv)
+		}
+		key, err := url.PathUnescape(kv[:i])
+		if err != nil {
+			return nil, err
+		}
+		quoted := kv[i+1:]
+		if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+			return nil, fmt.Errorf("invalid parameter %q", kv)
+		}
+		value, err := url.PathUnescape(quoted[1 : len(quoted)-1])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := params[key]; ok {
+			return nil, fmt.Errorf("duplicated parameter %q", key)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
@@ -0,0 +1,176 @@
+package oauth1
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testCredentialStore struct{}
+
+func (testCredentialStore) LookupConsumer(consumerKey string) (Signer, error) {
+	if consumerKey != "dpf43f3p2l4k3l03" {
+		return nil, errors.New("no such consumer")
+	}
+	return &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}, nil
+}
+
+func (testCredentialStore) LookupToken(consumerKey, token string) (string, error) {
+	if token != "nnch734d00sl2jdk" {
+		return "", errors.New("no such token")
+	}
+	return "pfkkdhi9sl3r4s00", nil
+}
+
+func newTestAuthenticatorServer(t *testing.T, a *Authenticator) *httptest.Server {
+	return httptest.NewServer(a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, ok := AuthorizationFromContext(r.Context())
+		if !ok {
+			t.Error("no authorization in the context")
+		}
+		if auth.ConsumerKey != "dpf43f3p2l4k3l03" || auth.Token != "nnch734d00sl2jdk" {
+			t.Errorf("unexpected authorization: %+v", auth)
+		}
+		w.Write([]byte(r.FormValue("title")))
+	})))
+}
+
+func testAuthenticatorAccept(t *testing.T) {
+	server := newTestAuthenticatorServer(t, &Authenticator{Store: testCredentialStore{}})
+	defer server.Close()
+
+	client := NewHTTPClient("dpf43f3p2l4k3l03", "kd94hf93k423kf44", "nnch734d00sl2jdk", "pfkkdhi9sl3r4s00")
+	form := url.Values{}
+	form.Set("title", "日本語 タイトル")
+	resp, err := client.PostForm(server.URL+"/post?a=b+c&a=a", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("%d != %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func testAuthenticatorReject(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	server := newTestAuthenticatorServer(t, &Authenticator{
+		Store: testCredentialStore{},
+		Clock: func() time.Time { return now },
+	})
+	defer server.Close()
+
+	cases := []struct {
+		name      string
+		transport *Transport
+	}{
+		{
+			name: "wrong consumer secret",
+			transport: &Transport{
+				ConsumerKey: "dpf43f3p2l4k3l03",
+				OAuthToken:  Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"},
+				Signer:      &HMACSHA1{ConsumerSecret: "wrong"},
+				Clock:       func() time.Time { return now },
+			},
+		},
+		{
+			name: "unknown token",
+			transport: &Transport{
+				ConsumerKey: "dpf43f3p2l4k3l03",
+				OAuthToken:  Token{Token: "unknown", Secret: "pfkkdhi9sl3r4s00"},
+				Signer:      &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+				Clock:       func() time.Time { return now },
+			},
+		},
+		{
+			name: "stale timestamp",
+			transport: &Transport{
+				ConsumerKey: "dpf43f3p2l4k3l03",
+				OAuthToken:  Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"},
+				Signer:      &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+				Clock:       func() time.Time { return now.Add(-time.Hour) },
+			},
+		},
+		{
+			name: "empty nonce",
+			transport: &Transport{
+				ConsumerKey: "dpf43f3p2l4k3l03",
+				OAuthToken:  Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"},
+				Signer:      &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+				Clock:       func() time.Time { return now },
+				NonceFunc:   func() string { return "" },
+			},
+		},
+	}
+	for _, c := range cases {
+		resp, err := (&http.Client{Transport: c.transport}).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: %d != %d", c.name, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+}
+
+func testAuthenticatorReplay(t *testing.T) {
+	server := newTestAuthenticatorServer(t, &Authenticator{Store: testCredentialStore{}})
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			ConsumerKey: "dpf43f3p2l4k3l03",
+			OAuthToken:  Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"},
+			Signer:      &HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+			NonceFunc:   func() string { return "chapoH" },
+		},
+	}
+	for i, code := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != code {
+			t.Errorf("request #%d: %d != %d", i, resp.StatusCode, code)
+		}
+	}
+}
+
+func testParseAuthorizationHeader(t *testing.T) {
+	params, err := parseAuthorizationHeader(`OAuth realm="Example", oauth_consumer_key="9djdj82h48djs9d2", oauth_signature="bYT5CMsGcbgUdFHObYMEfcx6bsw%3D"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["realm"] != "Example" || params["oauth_consumer_key"] != "9djdj82h48djs9d2" ||
+		params["oauth_signature"] != "bYT5CMsGcbgUdFHObYMEfcx6bsw=" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	for _, v := range []string{
+		``,
+		`Basic dXNlcjpwYXNz`,
+		`OAuth oauth_consumer_key=9djdj82h48djs9d2`,
+		`OAuth oauth_nonce="a", oauth_nonce="b"`,
+	} {
+		_, err := parseAuthorizationHeader(v)
+		if err == nil {
+			t.Errorf("expected error on %q", v)
+		}
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	t.Run("Accept", testAuthenticatorAccept)
+	t.Run("Reject", testAuthenticatorReject)
+	t.Run("Replay", testAuthenticatorReplay)
+	t.Run("ParseAuthorizationHeader", testParseAuthorizationHeader)
+}
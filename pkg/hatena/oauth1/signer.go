@@ -1,9 +1,14 @@
 package oauth1
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"sort"
@@ -19,6 +24,17 @@ type Signer interface {
 	Sign(key string, message string) string
 }
 
+// Verifier is an interface to verify a signature generated by Signer.
+type Verifier interface {
+	Method() string
+
+	Verify(key string, message string, signature string) error
+}
+
+// ErrInvalidSignature is returned by Verifier when the signature does not
+// match.
+var ErrInvalidSignature = errors.New("invalid signature")
+
 // HMACSHA1 is an implementation of Signer
 //
 // RFC 5849 - 3.4.2 HMAC-SHA1
@@ -31,42 +47,167 @@ func (s *HMACSHA1) Method() string {
 }
 
 func (s *HMACSHA1) Sign(tokenSecret string, text string) string {
-	key := url.QueryEscape(s.ConsumerSecret) + "&" + url.QueryEscape(tokenSecret)
+	key := percentEncode(s.ConsumerSecret) + "&" + percentEncode(tokenSecret)
 	mac := hmac.New(sha1.New, []byte(key))
 	mac.Write([]byte(text))
 	bytes := mac.Sum(nil)
 	return base64.StdEncoding.EncodeToString(bytes)
 }
 
+func (s *HMACSHA1) Verify(tokenSecret string, text string, signature string) error {
+	expected, err := base64.StdEncoding.DecodeString(s.Sign(tokenSecret, text))
+	if err != nil {
+		return err
+	}
+	actual, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal(expected, actual) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// PLAINTEXT is an implementation of Signer.  The signature is the consumer
+// secret and the token secret, and the message is not signed.  It should be
+// used only over TLS.
+//
+// RFC 5849 - 3.4.4 PLAINTEXT
+type PLAINTEXT struct {
+	ConsumerSecret string
+}
+
+func (s *PLAINTEXT) Method() string {
+	return "PLAINTEXT"
+}
+
+func (s *PLAINTEXT) Sign(tokenSecret string, text string) string {
+	return percentEncode(s.ConsumerSecret) + "&" + percentEncode(tokenSecret)
+}
+
+func (s *PLAINTEXT) Verify(tokenSecret string, text string, signature string) error {
+	expected := s.Sign(tokenSecret, text)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
 // signatureText returns a base string of the signing by Signer.
 //
 // The oauthParams is a set of the OAuth parameter (such as // oauth_consumer_key).
-// The caller must exclude "realm" parameter.  The userParams is a set of the
-// optional parameter specified in request body or URL query.
+// The caller must exclude "realm" parameter.  The bodyParams is a set of the
+// parameter in the form-encoded request body.  The parameters in the URL
+// query are taken from the req.
 //
 // See: RFC 5849 - 3.4.1  Signature Base String
-func signatureText(req *http.Request, oauthParams map[string]string, userParams url.Values) string {
-	params := make(map[string][]string)
+func signatureText(req *http.Request, oauthParams map[string]string, bodyParams url.Values) string {
+	type param struct {
+		key   string
+		value string
+	}
+	var params []param
 	for k, v := range oauthParams {
-		params[url.QueryEscape(k)] = append(params[k], v)
+		params = append(params, param{percentEncode(k), percentEncode(v)})
 	}
-	for k, vs := range userParams {
-		params[url.QueryEscape(k)] = append(params[k], vs...)
+	for k, vs := range req.URL.Query() {
+		for _, v := range vs {
+			params = append(params, param{percentEncode(k), percentEncode(v)})
+		}
+	}
+	for k, vs := range bodyParams {
+		for _, v := range vs {
+			params = append(params, param{percentEncode(k), percentEncode(v)})
+		}
+	}
+
+	// RFC 5849 - 3.4.1.3.2. Parameters Normalization
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].key != params[j].key {
+			return params[i].key < params[j].key
+		}
+		return params[i].value < params[j].value
+	})
+
+	kvs := make([]string, 0, len(params))
+	for _, p := range params {
+		kvs = append(kvs, p.key+"="+p.value)
+	}
+	urlPart := percentEncode(baseStringURI(req.URL))
+	parameterPart := percentEncode(strings.Join(kvs, "&"))
+	return strings.Join([]string{strings.ToUpper(req.Method), urlPart, parameterPart}, "&")
+}
+
+// baseStringURI returns the URI in the base string with the lowercase scheme
+// and host, without the default port, the query and the fragment.
+//
+// See: RFC 5849 - 3.4.1.2. Base String URI
+func baseStringURI(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if len(port) > 0 && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host = host + ":" + port
 	}
+	p := u.EscapedPath()
+	if len(p) == 0 {
+		p = "/"
+	}
+	return scheme + "://" + host + p
+}
 
-	var keys []string
-	for k := range params {
-		keys = append(keys, k)
+// requestBodyParams returns the parameters in the request body if the body
+// is form-encoded.  The body of the request is left readable.
+//
+// See: RFC 5849 - 3.4.1.3.1. Parameter Sources
+func requestBodyParams(req *http.Request) (url.Values, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-www-form-urlencoded" {
+		return nil, nil
 	}
-	sort.Strings(keys)
 
-	var kvs []string
-	for _, k := range keys {
-		for _, v := range params[k] {
-			kvs = append(kvs, k+"="+url.QueryEscape(v))
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		body, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return url.ParseQuery(string(body))
+}
+
+// percentEncode encodes the string by the percent-encoding in RFC 3986.  It
+// keeps only unreserved characters, and encodes spaces as "%20".
+//
+// See: RFC 5849 - 3.6. Percent Encoding
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
 		}
+		b.WriteByte('%')
+		b.WriteByte("0123456789ABCDEF"[c>>4])
+		b.WriteByte("0123456789ABCDEF"[c&15])
 	}
-	urlPart := url.QueryEscape(req.URL.Scheme + "://" + req.URL.Host + req.URL.Path)
-	parameterPart := url.QueryEscape(strings.Join(kvs, "&"))
-	return strings.Join([]string{req.Method, urlPart, parameterPart}, "&")
+	return b.String()
 }
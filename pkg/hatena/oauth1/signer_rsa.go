@@ -0,0 +1,127 @@
+package oauth1
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// RSASHA1 is an implementation of Signer and Verifier.  The message is signed
+// by the private key of the consumer, and the token secret is not used.
+//
+// RFC 5849 - 3.4.3 RSA-SHA1
+type RSASHA1 struct {
+	// PrivateKey is a private key to sign the message.  It is required only
+	// on signing.
+	PrivateKey *rsa.PrivateKey
+	// PublicKey is a public key to verify the signature.  The public key of
+	// the PrivateKey is used if nil.
+	PublicKey *rsa.PublicKey
+}
+
+// NewRSASHA1FromPEM returns a new RSASHA1 with the PEM-encoded private key in
+// PKCS #1 or PKCS #8.
+func NewRSASHA1FromPEM(data []byte) (*RSASHA1, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &RSASHA1{PrivateKey: key}, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key %T", key)
+		}
+		return &RSASHA1{PrivateKey: rsaKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+}
+
+// NewRSASHA1VerifierFromPEM returns a new RSASHA1 to verify signatures with
+// the PEM-encoded public key or certificate of the consumer.
+func NewRSASHA1VerifierFromPEM(data []byte) (*RSASHA1, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key %T", key)
+	}
+	return &RSASHA1{PublicKey: rsaKey}, nil
+}
+
+func (s *RSASHA1) Method() string {
+	return "RSA-SHA1"
+}
+
+// Sign signs the text by the private key.  It panics if the private key is
+// not set or invalid.
+func (s *RSASHA1) Sign(tokenSecret string, text string) string {
+	if s.PrivateKey == nil {
+		panic("oauth1: RSASHA1 has no private key")
+	}
+	digest := sha1.Sum([]byte(text))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA1, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func (s *RSASHA1) Verify(tokenSecret string, text string, signature string) error {
+	key := s.PublicKey
+	if key == nil && s.PrivateKey != nil {
+		key = &s.PrivateKey.PublicKey
+	}
+	if key == nil {
+		return errors.New("RSASHA1 has no public key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	digest := sha1.Sum([]byte(text))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA1, digest[:], sig) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package oauth1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func testRSASHA1SignAndVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	signer, err := NewRSASHA1FromPEM(privPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewRSASHA1VerifierFromPEM(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Method() != "RSA-SHA1" {
+		t.Errorf("%q != %q", signer.Method(), "RSA-SHA1")
+	}
+
+	sig := signer.Sign("", "GET&http%3A%2F%2Fexample.com%2F&a%3Db")
+	err = verifier.Verify("", "GET&http%3A%2F%2Fexample.com%2F&a%3Db", sig)
+	if err != nil {
+		t.Error(err)
+	}
+	err = verifier.Verify("", "GET&http%3A%2F%2Fexample.com%2F&a%3Dc", sig)
+	if err != ErrInvalidSignature {
+		t.Errorf("%v != %v", err, ErrInvalidSignature)
+	}
+	err = signer.Verify("", "GET&http%3A%2F%2Fexample.com%2F&a%3Db", sig)
+	if err != nil {
+		t.Errorf("signer must verify by its public key: %v", err)
+	}
+}
+
+func testRSASHA1InvalidPEM(t *testing.T) {
+	_, err := NewRSASHA1FromPEM([]byte("not a pem"))
+	if err == nil {
+		t.Error("expected error on invalid PEM")
+	}
+	_, err = NewRSASHA1VerifierFromPEM(pem.EncodeToMemory(&pem.Block{Type: "UNKNOWN", Bytes: []byte{}}))
+	if err == nil {
+		t.Error("expected error on unknown PEM type")
+	}
+}
+
+func TestRSASHA1(t *testing.T) {
+	t.Run("SignAndVerify", testRSASHA1SignAndVerify)
+	t.Run("InvalidPEM", testRSASHA1InvalidPEM)
+}
@@ -1,6 +1,12 @@
 package oauth1
 
-import "testing"
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
 
 func testHMACSHA1Sign(t *testing.T) {
 	s := HMACSHA1{ConsumerSecret: "xxxxxxxxxxxxxx=="}
@@ -10,6 +16,150 @@ func testHMACSHA1Sign(t *testing.T) {
 	}
 }
 
+// RFC 5849 - 1.2. Example
+func testHMACSHA1SignRFC5849(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://photos.example.net/photos?file=vacation.jpg&size=original", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     "dpf43f3p2l4k3l03",
+		"oauth_token":            "nnch734d00sl2jdk",
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        "137131202",
+		"oauth_nonce":            "chapoH",
+	}
+	text := signatureText(req, oauthParams, nil)
+
+	s := HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}
+	digest := s.Sign("pfkkdhi9sl3r4s00", text)
+	if digest != "MdpQcU8iPSUjWoN/UDMsK2sui9I=" {
+		t.Errorf(`"%s" != "MdpQcU8iPSUjWoN/UDMsK2sui9I="`, digest)
+	}
+}
+
+// RFC 5849 - 3.4.1.1. String Construction
+func testSignatureTextRFC5849(t *testing.T) {
+	body := "c2&a3=2+q"
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	bodyParams, err := requestBodyParams(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     "9djdj82h48djs9d2",
+		"oauth_token":            "kkk9d7dh3k39sjv7",
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        "137131201",
+		"oauth_nonce":            "7d8f3e4a",
+	}
+	text := signatureText(req, oauthParams, bodyParams)
+
+	expected := "POST&http%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q" +
+		"%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_consumer_" +
+		"key%3D9djdj82h48djs9d2%26oauth_nonce%3D7d8f3e4a%26oauth_signature_m" +
+		"ethod%3DHMAC-SHA1%26oauth_timestamp%3D137131201%26oauth_token%3Dkkk" +
+		"9d7dh3k39sjv7"
+	if text != expected {
+		t.Errorf("%q != %q", text, expected)
+	}
+
+	rest, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != body {
+		t.Errorf("request body must be kept: %q != %q", rest, body)
+	}
+}
+
+func testBaseStringURI(t *testing.T) {
+	cases := []struct {
+		src    string
+		result string
+	}{
+		{src: "HTTP://EXAMPLE.COM:80/r%20v/X?id=123", result: "http://example.com/r%20v/X"},
+		{src: "https://www.example.net:8080/?q=1", result: "https://www.example.net:8080/"},
+		{src: "https://www.example.net:443", result: "https://www.example.net/"},
+	}
+	for _, c := range cases {
+		u, err := url.Parse(c.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := baseStringURI(u); s != c.result {
+			t.Errorf("%q != %q", s, c.result)
+		}
+	}
+}
+
+func testPercentEncode(t *testing.T) {
+	cases := []struct {
+		src    string
+		result string
+	}{
+		{src: "abcABC123-._~", result: "abcABC123-._~"},
+		{src: "a b+c", result: "a%20b%2Bc"},
+		{src: "=%&*", result: "%3D%25%26%2A"},
+		{src: "日本", result: "%E6%97%A5%E6%9C%AC"},
+	}
+	for _, c := range cases {
+		if s := percentEncode(c.src); s != c.result {
+			t.Errorf("%q != %q", s, c.result)
+		}
+	}
+}
+
+func testHMACSHA1Verify(t *testing.T) {
+	s := HMACSHA1{ConsumerSecret: "xxxxxxxxxxxxxx=="}
+	err := s.Verify("yyyyyyyyyyyyyyyy", "text", "5MbWEwPzYkw/d5l8SErbJDpi0R8=")
+	if err != nil {
+		t.Error(err)
+	}
+	err = s.Verify("yyyyyyyyyyyyyyyy", "other text", "5MbWEwPzYkw/d5l8SErbJDpi0R8=")
+	if err != ErrInvalidSignature {
+		t.Errorf("%v != %v", err, ErrInvalidSignature)
+	}
+}
+
 func TestHMACSHA1(t *testing.T) {
 	t.Run("Sign", testHMACSHA1Sign)
+	t.Run("SignRFC5849", testHMACSHA1SignRFC5849)
+	t.Run("Verify", testHMACSHA1Verify)
+}
+
+func testPLAINTEXTSign(t *testing.T) {
+	s := PLAINTEXT{ConsumerSecret: "djr9rjt0jd78jf88"}
+	sig := s.Sign("jjd99$tj88uiths3", "text")
+	if sig != "djr9rjt0jd78jf88&jjd99%24tj88uiths3" {
+		t.Errorf(`"%s" != "djr9rjt0jd78jf88&jjd99%%24tj88uiths3"`, sig)
+	}
+}
+
+func testPLAINTEXTVerify(t *testing.T) {
+	s := PLAINTEXT{ConsumerSecret: "djr9rjt0jd78jf88"}
+	err := s.Verify("jjd99$tj88uiths3", "text", "djr9rjt0jd78jf88&jjd99%24tj88uiths3")
+	if err != nil {
+		t.Error(err)
+	}
+	err = s.Verify("jjd99$tj88uiths3", "text", "djr9rjt0jd78jf88&")
+	if err != ErrInvalidSignature {
+		t.Errorf("%v != %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestPLAINTEXT(t *testing.T) {
+	t.Run("Sign", testPLAINTEXTSign)
+	t.Run("Verify", testPLAINTEXTVerify)
+}
+
+func TestSignatureText(t *testing.T) {
+	t.Run("RFC5849", testSignatureTextRFC5849)
+	t.Run("BaseStringURI", testBaseStringURI)
+	t.Run("PercentEncode", testPercentEncode)
 }
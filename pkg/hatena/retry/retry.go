@@ -0,0 +1,174 @@
+package retry
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of the attempts.
+	DefaultMaxAttempts = 3
+	// DefaultBaseDelay is the default delay before the first retry.
+	DefaultBaseDelay = 1 * time.Second
+	// DefaultMaxDelay is the default upper bound of the delay.
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// A Transport is an implementation of the http.RoundTripper to retry the
+// request on transient failures, such as network errors, 429 and 5xx.  It
+// waits with exponential backoff and jitter between attempts, or the
+// duration in the Retry-After header if the server presents it.
+//
+// Only idempotent requests are retried.  Place the Transport in front of the
+// authentication transports (wsse.Transport and oauth1.Transport) so that
+// every attempt is signed again with a fresh nonce and timestamp:
+//
+//    client := &http.Client{
+//        Transport: &retry.Transport{
+//            RoundTripper: &wsse.Transport{Username: username, Password: password},
+//        },
+//    }
+type Transport struct {
+	// MaxAttempts is the maximum number of the attempts including the first
+	// one.  DefaultMaxAttempts is used if zero.
+	MaxAttempts int
+	// BaseDelay is a delay before the first retry.  The delay is doubled on
+	// each retry.  DefaultBaseDelay is used if zero.
+	BaseDelay time.Duration
+	// MaxDelay is an upper bound of the delay.  The request is not retried
+	// if the server requires to wait longer than MaxDelay by the Retry-After
+	// header.  DefaultMaxDelay is used if zero.
+	MaxDelay time.Duration
+
+	http.RoundTripper
+}
+
+func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt := t.RoundTripper
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if !isIdempotent(r.Method) || (r.Body != nil && r.Body != http.NoBody && r.GetBody == nil) {
+		return rt.RoundTrip(r)
+	}
+
+	maxAttempts := t.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	// Each attempt sends a body by GetBody, so close the original body
+	// instead of the underlying transport
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := cloneRequest(r)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if attempt >= maxAttempts {
+			return resp, err
+		}
+		if err == nil && !apierror.IsRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if d > t.maxDelay() {
+					return resp, nil
+				}
+				delay = d
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a delay before the next attempt of the attempt-th failure.
+// The delay is randomized in [d/2, d) where d is doubled on each attempt.
+func (t Transport) backoff(attempt int) time.Duration {
+	base := t.BaseDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	d := base
+	for i := 1; i < attempt && d < t.maxDelay(); i++ {
+		d *= 2
+	}
+	if d > t.maxDelay() {
+		d = t.maxDelay()
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (t Transport) maxDelay() time.Duration {
+	if t.MaxDelay <= 0 {
+		return DefaultMaxDelay
+	}
+	return t.MaxDelay
+}
+
+// cloneRequest returns a copy of the request with a fresh body and header,
+// to allow the underlying transport to modify them on each attempt.
+func cloneRequest(r *http.Request) (*http.Request, error) {
+	req := r.Clone(r.Context())
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return req, nil
+}
+
+// retryAfter parses the value of Retry-After header in delay-seconds or
+// HTTP-date.
+func retryAfter(v string) (time.Duration, bool) {
+	if len(v) == 0 {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
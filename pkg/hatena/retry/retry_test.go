@@ -0,0 +1,200 @@
+package retry
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/wsse"
+)
+
+func testTransportRetry(t *testing.T) {
+	var attempts int
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		headers = append(headers, r.Header.Get("X-WSSE"))
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("%q != %q", body, "hello")
+		}
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			RoundTripper: &wsse.Transport{
+				Username: "alice", Password: "secret",
+			},
+		},
+	}
+	req, err := http.NewRequest(http.MethodPut, server.URL, bytes.NewBufferString("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("%d != %d", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 3 {
+		t.Errorf("%d != %d", attempts, 3)
+	}
+	if headers[0] == headers[1] || headers[1] == headers[2] {
+		t.Errorf("request must be signed on each attempt: %q", headers)
+	}
+}
+
+// closeTracker is a request body recording the Close.
+type closeTracker struct {
+	io.Reader
+	closed int
+}
+
+func (b *closeTracker) Close() error {
+	b.closed++
+	return nil
+}
+
+func testTransportCloseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := &closeTracker{Reader: strings.NewReader("hello")}
+	req.Body = body
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("hello")), nil
+	}
+	tr := &Transport{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if body.closed != 1 {
+		t.Errorf("the request body is closed %d times", body.closed)
+	}
+}
+
+func testTransportGiveUp(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("%d != %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if attempts != 2 {
+		t.Errorf("%d != %d", attempts, 2)
+	}
+}
+
+func testTransportNonIdempotent(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	}
+	resp, err := client.Post(server.URL, "text/plain", bytes.NewBufferString("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("%d != %d", attempts, 1)
+	}
+}
+
+func testTransportRetryAfterTooLong(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{MaxAttempts: 3, MaxDelay: time.Second},
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("%d != %d", attempts, 1)
+	}
+}
+
+func testRetryAfter(t *testing.T) {
+	cases := []struct {
+		value string
+		ok    bool
+		delay time.Duration
+	}{
+		{value: "", ok: false},
+		{value: "120", ok: true, delay: 120 * time.Second},
+		{value: "-1", ok: false},
+		{value: "Wed, 21 Oct 2015 07:28:00 GMT", ok: true, delay: 0},
+		{value: "soon", ok: false},
+	}
+	for _, c := range cases {
+		d, ok := retryAfter(c.value)
+		if ok != c.ok || d != c.delay {
+			t.Errorf("retryAfter(%q) = %v, %v", c.value, d, ok)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("Retry", testTransportRetry)
+	t.Run("CloseBody", testTransportCloseBody)
+	t.Run("GiveUp", testTransportGiveUp)
+	t.Run("NonIdempotent", testTransportNonIdempotent)
+	t.Run("RetryAfterTooLong", testTransportRetryAfterTooLong)
+	t.Run("RetryAfter", testRetryAfter)
+}
@@ -3,23 +3,37 @@ package user
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strings"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/apierror"
 )
 
+// User is a Hatena user authorized by the credentials.
 type User struct {
-	UserNmae        string `json:"user_name"`
+	URLName         string `json:"url_name"`
 	DisplayName     string `json:"display_name"`
 	ProfileImageURL string `json:"profile_image_url"`
 }
 
 type Client struct {
 	HTTPClient *http.Client
+
+	// BaseURL is a base URL of the API endpoint.  DefaultBaseURL is used if
+	// empty.
+	BaseURL string
 }
 
+// DefaultBaseURL is a base URL of the API to get the user.
+const DefaultBaseURL = "https://n.hatena.com"
+
+// GetUser returns the user authorized by the credentials of the client.
 func (c *Client) GetUser(ctx context.Context) (*User, error) {
-	url := "http://n.hatena.com/applications/my.json"
+	base := c.BaseURL
+	if len(base) == 0 {
+		base = DefaultBaseURL
+	}
+	url := strings.TrimSuffix(base, "/") + "/applications/my.json"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -32,11 +46,7 @@ func (c *Client) GetUser(ctx context.Context) (*User, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		respBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("server returns %d (%s): %s", resp.StatusCode, resp.Status, respBody)
+		return nil, apierror.FromResponse(resp)
 	}
 
 	var user User
@@ -45,5 +55,4 @@ func (c *Client) GetUser(ctx context.Context) (*User, error) {
 		return nil, err
 	}
 	return &user, nil
-
 }
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientGetUser(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/applications/my.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"url_name":"alice","display_name":"Alice","profile_image_url":"https://cdn.example.com/alice.png"}`))
+	}))
+	defer ts.Close()
+
+	c := &Client{HTTPClient: ts.Client(), BaseURL: ts.URL}
+	u, err := c.GetUser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.URLName != "alice" {
+		t.Errorf("%q != %q", u.URLName, "alice")
+	}
+	if u.DisplayName != "Alice" {
+		t.Errorf("%q != %q", u.DisplayName, "Alice")
+	}
+	if u.ProfileImageURL != "https://cdn.example.com/alice.png" {
+		t.Errorf("%q != %q", u.ProfileImageURL, "https://cdn.example.com/alice.png")
+	}
+}
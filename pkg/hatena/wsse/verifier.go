@@ -0,0 +1,189 @@
+package wsse
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ueokande/hatenactl/pkg/hatena/internal/replay"
+)
+
+// DefaultWindow is the default maximum difference of the Created in the
+// token from the current time.
+const DefaultWindow = 5 * time.Minute
+
+// A UsernameToken represents a value of the X-WSSE header.
+type UsernameToken struct {
+	Username       string
+	PasswordDigest string
+	Nonce          string
+	Created        string
+}
+
+// ParseUsernameToken parses a value of the X-WSSE header such as:
+//
+//    UsernameToken Username="alice", PasswordDigest="...", Nonce="...", Created="..."
+func ParseUsernameToken(v string) (UsernameToken, error) {
+	if !strings.HasPrefix(v, "UsernameToken ") {
+		return UsernameToken{}, errors.New("no UsernameToken")
+	}
+
+	var token UsernameToken
+	for _, kv := range strings.Split(strings.TrimPrefix(v, "UsernameToken "), ",") {
+		kv = strings.TrimSpace(kv)
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return UsernameToken{}, fmt.Errorf("invalid parameter %q", kv)
+		}
+		key, quoted := kv[:i], kv[i+1:]
+		if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+			return UsernameToken{}, fmt.Errorf("invalid parameter %q", kv)
+		}
+		value := quoted[1 : len(quoted)-1]
+
+		switch key {
+		case "Username":
+			token.Username = value
+		case "PasswordDigest":
+			token.PasswordDigest = value
+		case "Nonce":
+			token.Nonce = value
+		case "Created":
+			token.Created = value
+		}
+	}
+	if len(token.Username) == 0 || len(token.PasswordDigest) == 0 ||
+		len(token.Nonce) == 0 || len(token.Created) == 0 {
+		return UsernameToken{}, errors.New("missing parameters in UsernameToken")
+	}
+	return token, nil
+}
+
+// A PasswordStore is an interface to look up the password of the user on
+// verifying requests.
+type PasswordStore interface {
+	LookupPassword(username string) (string, error)
+}
+
+type usernameContextKey struct{}
+
+// UsernameFromContext returns the user name of the request authenticated by
+// the Verifier.
+func UsernameFromContext(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(usernameContextKey{}).(string)
+	return username, ok
+}
+
+// A Verifier verifies the X-WSSE header in requests.  It recomputes the
+// PasswordDigest by the password of the user, and rejects requests created
+// out of the time window or with reused nonces.
+type Verifier struct {
+	// Store looks up passwords of the users
+	Store PasswordStore
+
+	// Window is the maximum difference of the Created from the current
+	// time.  DefaultWindow is used if zero.
+	Window time.Duration
+	// NonceCacheSize is the maximum number of the nonces remembered to
+	// reject replayed requests.  replay.DefaultSize is used if zero.
+	NonceCacheSize int
+	// Clock returns the current time.  time.Now is used if nil.
+	Clock func() time.Time
+
+	once   sync.Once
+	nonces *replay.Cache
+}
+
+// Handler returns a middleware to authenticate requests.  It responds 401
+// Unauthorized on failures, or calls the next handler with the user name in
+// the request context.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, err := v.Verify(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `WSSE profile="UsernameToken"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), usernameContextKey{}, username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Verify verifies the X-WSSE header in the request, and returns the user
+// name.
+func (v *Verifier) Verify(r *http.Request) (string, error) {
+	token, err := ParseUsernameToken(r.Header.Get("X-WSSE"))
+	if err != nil {
+		return "", err
+	}
+
+	created, err := parseCreated(token.Created)
+	if err != nil {
+		return "", err
+	}
+	d := v.now().Sub(created)
+	if d < 0 {
+		d = -d
+	}
+	if d > v.window() {
+		return "", fmt.Errorf("stale Created %q", token.Created)
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(token.Nonce)
+	if err != nil {
+		return "", fmt.Errorf("invalid Nonce %q", token.Nonce)
+	}
+	password, err := v.Store.LookupPassword(token.Username)
+	if err != nil {
+		return "", fmt.Errorf("unknown user: %w", err)
+	}
+	digest := passwordDigest(nonce, token.Created, password)
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(token.PasswordDigest)) != 1 {
+		return "", errors.New("invalid PasswordDigest")
+	}
+
+	if v.nonceCache().Seen(token.Username+"&"+token.Nonce, v.now()) {
+		return "", errors.New("nonce already used")
+	}
+	return token.Username, nil
+}
+
+func (v *Verifier) nonceCache() *replay.Cache {
+	v.once.Do(func() {
+		// Nonces are kept while the Created is acceptable
+		v.nonces = &replay.Cache{Size: v.NonceCacheSize, TTL: 2 * v.window()}
+	})
+	return v.nonces
+}
+
+func (v *Verifier) window() time.Duration {
+	if v.Window <= 0 {
+		return DefaultWindow
+	}
+	return v.Window
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Clock != nil {
+		return v.Clock()
+	}
+	return time.Now()
+}
+
+// parseCreated parses the Created in ISO 8601 formats.
+func parseCreated(v string) (time.Time, error) {
+	for _, layout := range []string{iso8601, time.RFC3339} {
+		t, err := time.Parse(layout, v)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid Created %q", v)
+}
@@ -0,0 +1,146 @@
+package wsse
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testPasswordStore map[string]string
+
+func (s testPasswordStore) LookupPassword(username string) (string, error) {
+	password, ok := s[username]
+	if !ok {
+		return "", errors.New("no such user")
+	}
+	return password, nil
+}
+
+func newTestVerifierServer(t *testing.T, v *Verifier) *httptest.Server {
+	return httptest.NewServer(v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, ok := UsernameFromContext(r.Context())
+		if !ok || username != "alice" {
+			t.Errorf("unexpected user name: %q", username)
+		}
+	})))
+}
+
+func testVerifierRoundTrip(t *testing.T) {
+	server := newTestVerifierServer(t, &Verifier{Store: testPasswordStore{"alice": "secret"}})
+	defer server.Close()
+
+	for _, c := range []struct {
+		password string
+		code     int
+	}{
+		{password: "secret", code: http.StatusOK},
+		{password: "wrong", code: http.StatusUnauthorized},
+	} {
+		resp, err := NewHTTPClient("alice", c.password).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.code {
+			t.Errorf("%d != %d", resp.StatusCode, c.code)
+		}
+	}
+}
+
+func testVerifierReplay(t *testing.T) {
+	server := newTestVerifierServer(t, &Verifier{Store: testPasswordStore{"alice": "secret"}})
+	defer server.Close()
+
+	var header string
+	client := &http.Client{
+		Transport: &Transport{
+			Username: "alice",
+			Password: "secret",
+			RoundTripper: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				header = r.Header.Get("X-WSSE")
+				return http.DefaultTransport.RoundTrip(r)
+			}),
+		},
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("%d != %d", resp.StatusCode, http.StatusOK)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-WSSE", header)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("replayed request: %d != %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func testVerifierStale(t *testing.T) {
+	server := newTestVerifierServer(t, &Verifier{
+		Store: testPasswordStore{"alice": "secret"},
+		Clock: func() time.Time { return time.Now().Add(time.Hour) },
+	})
+	defer server.Close()
+
+	resp, err := NewHTTPClient("alice", "secret").Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("%d != %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func testParseUsernameToken(t *testing.T) {
+	token, err := ParseUsernameToken(`UsernameToken Username="alice", PasswordDigest="ZCNaK2jrXr4+zsCaYAlEpBaaJwU=", Nonce="ZTQ3YTEyMWM4ZDA2ZGI1ZQ==", Created="2003-12-15T14:43:07Z"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := UsernameToken{
+		Username:       "alice",
+		PasswordDigest: "ZCNaK2jrXr4+zsCaYAlEpBaaJwU=",
+		Nonce:          "ZTQ3YTEyMWM4ZDA2ZGI1ZQ==",
+		Created:        "2003-12-15T14:43:07Z",
+	}
+	if token != expected {
+		t.Errorf("%+v != %+v", token, expected)
+	}
+
+	for _, v := range []string{
+		``,
+		`UsernameToken Username="alice"`,
+		`UsernameToken Username=alice, PasswordDigest="x", Nonce="x", Created="x"`,
+	} {
+		_, err := ParseUsernameToken(v)
+		if err == nil {
+			t.Errorf("expected error on %q", v)
+		}
+	}
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestVerifier(t *testing.T) {
+	t.Run("RoundTrip", testVerifierRoundTrip)
+	t.Run("Replay", testVerifierReplay)
+	t.Run("Stale", testVerifierStale)
+	t.Run("ParseUsernameToken", testParseUsernameToken)
+}
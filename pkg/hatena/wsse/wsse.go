@@ -26,6 +26,9 @@ type Transport struct {
 }
 
 func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// RoundTripper should not modify the request
+	r = r.Clone(r.Context())
+
 	var nonce [16]byte
 	_, err := rand.Read(nonce[:])
 	if err != nil {
@@ -34,11 +37,9 @@ func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
 
 	now := time.Now().Format(iso8601)
 
-	token := bytes.Join([][]byte{nonce[:], []byte(now), []byte(t.Password)}, []byte{})
-	digest := sha1.Sum(token)
 	wsse := fmt.Sprintf("UsernameToken Username=%q, PasswordDigest=%q, Nonce=%q, Created=%q",
 		t.Username,
-		base64.StdEncoding.EncodeToString(digest[:]),
+		passwordDigest(nonce[:], now, t.Password),
 		base64.StdEncoding.EncodeToString(nonce[:]),
 		now)
 	r.Header.Set("X-WSSE", wsse)
@@ -49,6 +50,14 @@ func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return t.RoundTripper.RoundTrip(r)
 }
 
+// passwordDigest returns the PasswordDigest of the UsernameToken, which is
+// Base64(SHA1(Nonce + Created + Password)).
+func passwordDigest(nonce []byte, created string, password string) string {
+	token := bytes.Join([][]byte{nonce, []byte(created), []byte(password)}, []byte{})
+	digest := sha1.Sum(token)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
 func NewHTTPClient(username, password string) *http.Client {
 	return &http.Client{
 		Transport: &Transport{